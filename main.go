@@ -45,6 +45,26 @@ func main() {
 		defer wg.Done()
 		controllers.ProcessPatterns(ctx, dbH, qHolder, tlg)
 	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		controllers.ProcessExpiration(ctx, dbH, tlg)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		controllers.ProcessDeferred(ctx, dbH, tlg)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		controllers.ProcessDigests(ctx, dbH, tlg)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		controllers.ProcessAcks(ctx, dbH, tlg)
+	}()
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, os.Interrupt)
 	<-stopCh
@@ -3,9 +3,9 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
+	"math"
 	"strings"
+	"time"
 
 	"fx_alert/pkg/db"
 	"fx_alert/pkg/quoter"
@@ -14,201 +14,215 @@ import (
 
 type CommandType string
 
-var clearWhiteSpace = regexp.MustCompile(`\s+`)
-
 const (
 	AddValue    CommandType = "/add"
 	DeleteValue CommandType = "/del"
 	ListValues  CommandType = "/ls"
+	Edit        CommandType = "/edit"
 	DeltaValue  CommandType = "/delta"
-	Help        CommandType = "/help"
+	Grid        CommandType = "/grid"
+	// PercentValue is alert on percent change from reference price.
+	PercentValue CommandType = "/pct"
+	Trailing     CommandType = "/trail"
+	Range        CommandType = "/range"
+	Bracket      CommandType = "/bracket"
+	Link         CommandType = "/link"
+	Unlink       CommandType = "/unlink"
+	Rule         CommandType = "/rule"
+	Touch        CommandType = "/touch"
+	Retest       CommandType = "/retest"
+	Snooze       CommandType = "/snooze"
+	Mute         CommandType = "/mute"
+	Timezone     CommandType = "/tz"
+	Quiet        CommandType = "/quiet"
+	Digest       CommandType = "/digest"
+	Priority     CommandType = "/priority"
+	Ack          CommandType = "/ack"
+	Momentum     CommandType = "/momentum"
+	Help         CommandType = "/help"
 
 	NoValue = -1
 
 	AnySymbol = "*"
+
+	MaxGridLevels = 200
+
+	MaxDigestWindow = time.Hour
+
+	// DefaultTolerance is half width of zone around level for touches in points.
+	DefaultTolerance = 20
 )
 
-func CommandFromString(txt string) (CommandType, error) {
-	txt = strings.TrimSpace(strings.ToLower(txt))
-	switch {
-	case strings.HasPrefix(txt, string(AddValue)+" "):
-		return AddValue, nil
-	case strings.HasPrefix(txt, string(DeleteValue)+" ") || (txt == string(DeleteValue)):
-		return DeleteValue, nil
-	case strings.HasPrefix(txt, string(ListValues)+" ") || (txt == string(ListValues)):
-		return ListValues, nil
-	case strings.HasPrefix(txt, string(DeltaValue)+" "):
-		return DeltaValue, nil
-	case txt == string(Help):
-		return Help, nil
-	}
+// Actions of commands which manage own kind of values: /rule add|ls|del.
+const (
+	ActionAdd    = "add"
+	ActionList   = "ls"
+	ActionDelete = "del"
+)
 
-	return "", errors.New("Unsupported command")
+// UsageError is returned when command arguments don't match any supported form.
+type UsageError struct {
+	Command CommandType
+	Err     error
+	Usage   string
 }
 
-type CommandValue struct {
-	Command CommandType
-	Value   *db.Value
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("%s: %v\nUsage:\n%s", e.Command, e.Err, e.Usage)
 }
 
-func Parse(msg string) (*CommandValue, error) {
-	msg = strings.TrimSpace(strings.ToLower(msg))
-	msg = clearWhiteSpace.ReplaceAllString(msg, " ")
-	cmdT, err := CommandFromString(msg)
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+func CommandFromString(txt string) (CommandType, error) {
+	s, err := findSpec(txt)
 	if err != nil {
-		return nil, fmt.Errorf("Can't parse command: %w", err)
+		return "", err
 	}
 
-	if cmdT == ListValues {
-		if msg == string(ListValues) {
-			return &CommandValue{Command: ListValues}, nil
-		}
-		cv := &CommandValue{
-			Command: cmdT,
-			Value: &db.Value{
-				Key: strings.TrimSpace(strings.TrimPrefix(msg, string(ListValues))),
-			},
-		}
-		return cv, nil
+	return s.command, nil
+}
+
+// findSpec return spec of command by first word of message, aliases are supported.
+func findSpec(txt string) (*commandSpec, error) {
+	fields := strings.Fields(strings.ToLower(txt))
+	if len(fields) == 0 {
+		return nil, errors.New("Empty command")
 	}
-	if cmdT == Help {
-		return &CommandValue{Command: Help}, nil
+	name := fields[0]
+	// commands in groups can be sent as /add@bot_name
+	if pos := strings.Index(name, "@"); pos > 0 {
+		name = name[:pos]
 	}
-
-	if cmdT == AddValue {
-		v, err := parseValue(msg)
-		if err != nil {
-			return nil, err
+	for i := range specs {
+		if name == string(specs[i].command) {
+			return &specs[i], nil
 		}
-		cv := &CommandValue{
-			Command: cmdT,
-			Value:   v,
+		for _, alias := range specs[i].aliases {
+			if name == alias {
+				return &specs[i], nil
+			}
 		}
-
-		return cv, nil
 	}
 
-	if cmdT == DeleteValue {
-		if msg == string(DeleteValue) {
-			return &CommandValue{Command: DeleteValue}, nil
-		}
-		if strings.Count(msg, " ") == 1 {
-			cv := &CommandValue{
-				Command: cmdT,
-				Value: &db.Value{
-					Key:   strings.TrimSpace(strings.TrimPrefix(msg, string(DeleteValue))),
-					Value: NoValue,
-				},
-			}
-			return cv, nil
-		}
-		v, err := parseValue(msg)
-		if err != nil {
-			return nil, err
-		}
-		cv := &CommandValue{
-			Command: cmdT,
-			Value:   v,
-		}
+	return nil, errors.New("Unsupported command")
+}
 
-		return cv, nil
-	}
+type CommandValue struct {
+	Command CommandType
+	Value   *db.Value
+	// Relative is set when level must be resolved against the current price.
+	Relative *db.Offset
+	Grid     *GridSpec
+	Bracket  *BracketSpec
+	// IDs is list of stored values the command is applied to.
+	IDs []uint64
+	// Action is subcommand, e.g. ActionAdd.
+	Action string
+	// Until is end of snooze or mute, zero time cancels it.
+	Until time.Time
+	// Timezone is IANA name of timezone.
+	Timezone string
+	Quiet    *db.QuietHours
+	// Window is aggregation time of digest.
+	Window time.Duration
+	// Priority of notifications of Kind.
+	Priority db.Priority
+	Kind     db.NotificationKind
+	// MomentumRule is added or with ActionDelete removed, without rule momentum is enabled or with ActionDelete disabled.
+	MomentumRule *db.MomentumRule
+	// NoteSet is true if note was given, so empty note clears existing one.
+	NoteSet bool
+	// ValueSet is true if level was given, level of spread can be 0 or negative.
+	ValueSet bool
+}
 
-	if cmdT == DeltaValue {
-		v, err := parseDeltaValue(msg)
-		if err != nil {
-			return nil, err
-		}
-		cv := &CommandValue{
-			Command: cmdT,
-			Value:   v,
-		}
+// GridSpec is ladder of levels between From and To every Step points.
+type GridSpec struct {
+	From float64
+	To   float64
+	Step int64
+}
+
+// BracketSpec is pair of linked levels around the current price: by distance or by explicit levels.
+type BracketSpec struct {
+	Distance *db.Offset
+	Low      float64
+	High     float64
+}
 
-		return cv, nil
+// Levels return grid levels from lowest to highest.
+func (g GridSpec) Levels(symbol string) []float64 {
+	from, to := math.Min(g.From, g.To), math.Max(g.From, g.To)
+	step := quoter.FromPoints(symbol, g.Step)
+	p := math.Pow10(int(quoter.GetPrecision(symbol)))
+	var lvls []float64
+	for i := 0; i < MaxGridLevels; i++ {
+		lvl := math.Round((from+float64(i)*step)*p) / p
+		if lvl > to {
+			break
+		}
+		lvls = append(lvls, lvl)
 	}
 
-	return nil, errors.New("Unsupported command")
+	return lvls
 }
 
-func parseValue(msg string) (*db.Value, error) {
-	parts := strings.Split(msg, " ")
-	if len(parts) != 4 {
-		return nil, errors.New("Unsupported command format")
-	}
-	k := strings.ToUpper(parts[1])
-	vt, err := db.ValueTypeFromString(parts[2])
-	if err != nil {
-		return nil, fmt.Errorf("Can't parse value type: %w", err)
+// ParseBatch parse message with one command per line.
+func ParseBatch(msg string) ([]CommandValue, error) {
+	var cmds []CommandValue
+	for i, line := range strings.Split(msg, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cmd, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %w", i+1, err)
+		}
+		cmds = append(cmds, *cmd)
 	}
-	rawVal := strings.ReplaceAll(parts[3], ",", ".")
-	v, err := strconv.ParseFloat(rawVal, 64)
-	if err != nil {
-		return nil, fmt.Errorf("Can't parse value: %q. %w", rawVal, err)
+	if len(cmds) == 0 {
+		return nil, errors.New("Empty message")
 	}
 
-	return &db.Value{
-		Key:       k,
-		Value:     v,
-		Type:      vt,
-		Precision: quoter.GetPrecision(k),
-	}, nil
+	return cmds, nil
 }
 
-func parseDeltaValue(msg string) (*db.Value, error) {
-	parts := strings.Split(msg, " ")
-	ln := len(parts)
-	if (ln != 2) && (ln != 3) {
-		return nil, errors.New("Unsupported command format")
+func Parse(msg string) (*CommandValue, error) {
+	tokens, err := tokenize(msg)
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse command: %w", err)
 	}
-	var rawVal string
-	var k string
-	if ln == 2 {
-		rawVal = parts[1]
-	} else {
-		rawVal = parts[2]
-		k = parts[1]
+	if len(tokens) == 0 {
+		return nil, errors.New("Can't parse command: Empty command")
 	}
-	rawVal = strings.ReplaceAll(rawVal, ",", ".")
-	v, err := strconv.ParseInt(rawVal, 10, 64)
+	spec, err := findSpec(tokens[0].text)
 	if err != nil {
-		return nil, fmt.Errorf("Can't parse delta value: %q. %w", rawVal, err)
-	}
-	if v <= 0 {
-		return nil, errors.New("Delta value must be > 0")
+		return nil, fmt.Errorf("Can't parse command: %w", err)
 	}
 
-	return &db.Value{Key: k, Value: float64(v)}, nil
+	return spec.parse(tokens[1:])
 }
 
+// HelpAnswer is generated from command specs.
 func HelpAnswer() *telegram.Answer {
-	answer := fmt.Sprintf(
-		`
-Add: %[3]s EURUSD %[1]s 1.2550
-
-Delete: %[4]s EURUSD %[2]s 1.2550
-Delete: %[4]s EURUSD
-Delete: %[4]s EUR
-Delete: %[4]s *
-
-Keyboard delete: %[4]s
-
-List: %[5]s
-List: %[5]s USD
-
-Delta: %[6]s USDJPY 500
-Delta: %[6]s USD 500
-Delta: %[6]s 500
-
-Help: %[7]s
-`,
-		db.AboveCurrent,
-		db.BelowCurrent,
-		AddValue,
-		DeleteValue,
-		ListValues,
-		DeltaValue,
-		Help,
-	)
-
-	return &telegram.Answer{Text: answer}
+	var blocks []string
+	for _, s := range specs {
+		var lines []string
+		for _, f := range s.forms {
+			title := f.title
+			if title == "" {
+				title = s.title
+			}
+			lines = append(lines, strings.TrimSpace(fmt.Sprintf("%s: %s %s", title, s.command, f.example)))
+		}
+		if len(s.aliases) > 0 {
+			lines = append(lines, "Aliases: "+strings.Join(s.aliases, ", "))
+		}
+		lines = append(lines, s.notes...)
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return &telegram.Answer{Text: strings.Join(blocks, "\n\n")}
 }
@@ -0,0 +1,809 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fx_alert/pkg/db"
+)
+
+func TestParseAddValue(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect *db.Value
+	}
+
+	data := []tableData{
+		{
+			msg:    "/add EURUSD > 1.2550",
+			expect: &db.Value{Key: "EURUSD", Value: 1.255, Type: db.BelowCurrent, Precision: 5},
+		},
+		{
+			msg:    "/add usdjpy < 110,5",
+			expect: &db.Value{Key: "USDJPY", Value: 110.5, Type: db.AboveCurrent, Precision: 3},
+		},
+		{
+			msg:    "/add EURUSD 1.2550",
+			expect: &db.Value{Key: "EURUSD", Value: 1.255, Precision: 5},
+		},
+		{
+			msg:    "/add EURUSD = 1.2550",
+			expect: nil,
+		},
+		{
+			msg:    "/add EURUSD",
+			expect: nil,
+		},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(d.expect, cv.Value) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv.Value)
+		}
+	}
+}
+
+func TestParseRelativeValue(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect *db.Offset
+		vt     db.ValueType
+	}
+
+	data := []tableData{
+		{msg: "/add EURUSD +50p", expect: &db.Offset{Amount: 50, Unit: db.OffsetPoints}, vt: db.BelowCurrent},
+		{msg: "/add GBPJPY -0.3%", expect: &db.Offset{Amount: -0.3, Unit: db.OffsetPercent}, vt: db.AboveCurrent},
+		{msg: "/add BTCUSD +2atr", expect: &db.Offset{Amount: 2, Unit: db.OffsetATR}, vt: db.BelowCurrent},
+		{msg: "/add EURUSD +1.5p", expect: nil},
+		{msg: "/add EURUSD +50", expect: nil},
+		{msg: "/add EURUSD -0atr", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(d.expect, cv.Relative) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv.Relative)
+		}
+		if cv.Value.Type != d.vt {
+			t.Fatalf("Test %d Expect type: %q, got %q", i, d.vt, cv.Value.Type)
+		}
+	}
+}
+
+func TestParseGrid(t *testing.T) {
+	cv, err := Parse("/grid EURUSD 1.1010 1.1000 step 20")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lvls := cv.Grid.Levels(cv.Value.Key)
+	expect := []float64{1.1, 1.1002, 1.1004, 1.1006, 1.1008, 1.101}
+	if !reflect.DeepEqual(expect, lvls) {
+		t.Fatalf("Expect: %v, got %v", expect, lvls)
+	}
+	if _, err := Parse("/grid EURUSD 1.1000 1.2000 step 1"); err == nil {
+		t.Fatal("Expect error for too many levels")
+	}
+}
+
+func TestParseBatch(t *testing.T) {
+	cmds, err := ParseBatch("/add EURUSD > 1.2\n\n/add GBPUSD 1.3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("Expect 2 commands, got %d", len(cmds))
+	}
+	if _, err := ParseBatch("/add EURUSD > 1.2\n/add GBPUSD ? 1.3"); err == nil {
+		t.Fatal("Expect error for invalid line")
+	}
+}
+
+func TestParseCommands(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect *CommandValue
+	}
+
+	data := []tableData{
+		{msg: "/a EURUSD > 1.2", expect: &CommandValue{Command: AddValue, Value: &db.Value{Key: "EURUSD", Value: 1.2, Type: db.BelowCurrent, Precision: 5}}},
+		{msg: "/add eur/usd > 1.2", expect: &CommandValue{Command: AddValue, Value: &db.Value{Key: "EURUSD", Value: 1.2, Type: db.BelowCurrent, Precision: 5}}},
+		{msg: "/add cable < 1.2", expect: &CommandValue{Command: AddValue, Value: &db.Value{Key: "GBPUSD", Value: 1.2, Type: db.AboveCurrent, Precision: 5}}},
+		{msg: "/rm", expect: &CommandValue{Command: DeleteValue}},
+		{msg: "/rm eur", expect: &CommandValue{Command: DeleteValue, Value: &db.Value{Key: "EUR", Value: NoValue}}},
+		{msg: "/del *", expect: &CommandValue{Command: DeleteValue, Value: &db.Value{Key: AnySymbol, Value: NoValue}}},
+		{msg: "/ls@fx_bot usd", expect: &CommandValue{Command: ListValues, Value: &db.Value{Key: "USD"}}},
+		{msg: "/delta  500", expect: &CommandValue{Command: DeltaValue, Value: &db.Value{Value: 500}}},
+		{msg: "/delta usd 500", expect: &CommandValue{Command: DeltaValue, Value: &db.Value{Key: "USD", Value: 500}}},
+		{msg: "/start", expect: &CommandValue{Command: Help}},
+		{msg: "/unknown", expect: nil},
+		{msg: "/help me", expect: nil},
+		{msg: "/delta -1", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(d.expect, cv) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv)
+		}
+	}
+}
+
+func TestUsageError(t *testing.T) {
+	_, err := Parse("/grid EURUSD 1.1 1.2 by 20")
+	var usageErr *UsageError
+	if !errors.As(err, &usageErr) {
+		t.Fatalf("Expect usage error, got %v", err)
+	}
+	if !strings.HasPrefix(usageErr.Usage, "/grid SYMBOL FROM TO step POINTS\n") {
+		t.Fatalf("Unexpected usage: %q", usageErr.Usage)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`/add  EURUSD "Weekly resistance"`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect := []token{{text: "/add"}, {text: "EURUSD"}, {text: "Weekly resistance", quoted: true}}
+	if !reflect.DeepEqual(expect, tokens) {
+		t.Fatalf("Expect: %#v, got %#v", expect, tokens)
+	}
+	if _, err := tokenize(`/add "note`); err == nil {
+		t.Fatal("Expect error for unclosed quote")
+	}
+}
+
+func TestParseTimeInForce(t *testing.T) {
+	current := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	type tableData struct {
+		msg     string
+		tif     db.TimeInForce
+		expires time.Time
+		err     bool
+	}
+
+	data := []tableData{
+		{msg: "/add EURUSD > 1.2", tif: "", expires: time.Time{}},
+		{msg: "/add EURUSD > 1.2 gtc", tif: db.GoodTillCancelled, expires: time.Time{}},
+		{msg: "/add EURUSD 1.2 eod", tif: db.EndOfDay, expires: time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{msg: "/add EURUSD +50p gtd 4h", tif: db.GoodTillDate, expires: current.Add(4 * time.Hour)},
+		{msg: "/add EURUSD > 1.2 GTD 2021-06-03", tif: db.GoodTillDate, expires: time.Date(2021, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{msg: "/add EURUSD > 1.2 gtd 2021-06-03T15:00", tif: db.GoodTillDate, expires: time.Date(2021, 6, 3, 15, 0, 0, 0, time.UTC)},
+		{msg: "/add EURUSD > 1.2 gtd 2021-05-03", err: true},
+		{msg: "/add EURUSD > 1.2 gtd", err: true},
+		{msg: "/add EURUSD > 1.2 gtc extra", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.TimeInForce != d.tif) || !cv.Value.ExpiresAt.Equal(d.expires) {
+			t.Fatalf("Test %d Expect: %q %v, got %q %v", i, d.tif, d.expires, cv.Value.TimeInForce, cv.Value.ExpiresAt)
+		}
+	}
+}
+
+func TestParseRepeat(t *testing.T) {
+	cv, err := Parse("/add EURUSD > 1.2 cooldown 30m max 5 rearm 20 gtd 2d")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	v := cv.Value
+	if !v.Repeat || (v.Cooldown != 30*time.Minute) || (v.MaxCount != 5) || (v.Rearm != 20) || (v.TimeInForce != db.GoodTillDate) {
+		t.Fatalf("Unexpected value: %#v", v)
+	}
+	if _, err := Parse("/add EURUSD > 1.2 cooldown 0s"); err == nil {
+		t.Fatal("Expect error for zero cooldown")
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	cv, err := Parse(`/add EURUSD > 1.2 #Weekly "Weekly resistance" gtc #tp_short`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cv.Value.Note != "Weekly resistance" {
+		t.Fatalf("Unexpected note: %q", cv.Value.Note)
+	}
+	if !reflect.DeepEqual([]string{"weekly", "tp_short"}, cv.Value.Tags) {
+		t.Fatalf("Unexpected tags: %v", cv.Value.Tags)
+	}
+	if _, err := Parse("/add EURUSD > 1.2 #"); err == nil {
+		t.Fatal("Expect error for empty tag")
+	}
+}
+
+func TestParseEdit(t *testing.T) {
+	type tableData struct {
+		msg      string
+		expect   *db.Value
+		valueSet bool
+	}
+
+	data := []tableData{
+		{msg: "/edit 12 1.26", expect: &db.Value{ID: 12, Value: 1.26}, valueSet: true},
+		{msg: "/edit #12 <", expect: &db.Value{ID: 12, Type: db.AboveCurrent}},
+		{msg: "/edit 12 > 1.26", expect: &db.Value{ID: 12, Type: db.BelowCurrent, Value: 1.26}, valueSet: true},
+		{msg: "/edit 12 < -0.15", expect: &db.Value{ID: 12, Type: db.AboveCurrent, Value: -0.15}, valueSet: true},
+		{msg: "/edit 12 0", expect: &db.Value{ID: 12}, valueSet: true},
+		{msg: `/edit 12 "new note"`, expect: &db.Value{ID: 12, Note: "new note"}},
+		{msg: "/edit 0 1.26", expect: nil},
+		{msg: "/edit EURUSD 1.26", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(d.expect, cv.Value) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv.Value)
+		}
+		if cv.ValueSet != d.valueSet {
+			t.Fatalf("Test %d Expect value set: %v, got %v", i, d.valueSet, cv.ValueSet)
+		}
+	}
+	cv, err := Parse("/del 12")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cv.Value.ID != 12 {
+		t.Fatalf("Unexpected delete value: %#v", cv.Value)
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	type tableData struct {
+		msg    string
+		vt     db.ValueType
+		expect *db.PercentChange
+	}
+
+	data := []tableData{
+		{msg: "/pct EURUSD 0.5", vt: db.CrossAny, expect: &db.PercentChange{Percent: 0.5, Reference: db.ReferenceSet}},
+		{msg: "/pct EURUSD +0.5%", vt: db.BelowCurrent, expect: &db.PercentChange{Percent: 0.5, Reference: db.ReferenceSet}},
+		{msg: "/pct GBPUSD -0.3% open", vt: db.AboveCurrent, expect: &db.PercentChange{Percent: 0.3, Reference: db.ReferenceOpen}},
+		{msg: "/pct GBPUSD 0", expect: nil},
+		{msg: "/pct GBPUSD 1 close", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Kind != db.KindPercent) || (cv.Value.Type != d.vt) || !reflect.DeepEqual(d.expect, cv.Value.Percent) {
+			t.Fatalf("Test %d Expect: %q %#v, got %#v", i, d.vt, d.expect, cv.Value)
+		}
+	}
+	cv, err := Parse("/del [12] EURUSD x 0.5% from open")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cv.Value.ID != 12 {
+		t.Fatalf("Unexpected delete value: %#v", cv.Value)
+	}
+}
+
+func TestParseTrailing(t *testing.T) {
+	cv, err := Parse("/trail EURUSD short 0.5%")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect := &db.Trailing{Distance: db.Offset{Amount: 0.5, Unit: db.OffsetPercent}}
+	if (cv.Value.Kind != db.KindTrailing) || (cv.Value.Type != db.BelowCurrent) || !reflect.DeepEqual(expect, cv.Value.Trailing) {
+		t.Fatalf("Unexpected value: %#v", cv.Value)
+	}
+	for _, msg := range []string{"/trail EURUSD long -50p", "/trail EURUSD up 50p", "/trail EURUSD long 50"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect *db.Range
+	}
+
+	data := []tableData{
+		{msg: "/range EURUSD 1.1000 1.1200", expect: &db.Range{Low: 1.1, High: 1.12, Mode: db.RangeExit}},
+		{msg: "/range eurusd 1.12 1.1 enter", expect: &db.Range{Low: 1.1, High: 1.12, Mode: db.RangeEnter}},
+		{msg: "/range EURUSD 1.1 1.1", expect: nil},
+		{msg: "/range EURUSD 1.1 1.12 inside", expect: nil},
+		{msg: "/range EURUSD 1.1", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv.Value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Kind != db.KindRange) || (cv.Value.Key != "EURUSD") || !reflect.DeepEqual(d.expect, cv.Value.Range) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv.Value.Range)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	type tableData struct {
+		msg     string
+		ids     []uint64
+		bracket *BracketSpec
+		err     bool
+	}
+
+	data := []tableData{
+		{msg: "/link 12 14", ids: []uint64{12, 14}},
+		{msg: "/oco [12] #14 12 3", ids: []uint64{12, 14, 3}},
+		{msg: "/link 12", err: true},
+		{msg: "/link 12 abc", err: true},
+		{msg: "/unlink 12", ids: []uint64{12}},
+		{msg: "/bracket EURUSD 50p", bracket: &BracketSpec{Distance: &db.Offset{Amount: 50, Unit: db.OffsetPoints}}},
+		{msg: "/bracket EURUSD 1.11 1.095", bracket: &BracketSpec{Low: 1.095, High: 1.11}},
+		{msg: "/bracket EURUSD 1.11 1.11", err: true},
+		{msg: "/bracket EURUSD -50p", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(d.ids, cv.IDs) || !reflect.DeepEqual(d.bracket, cv.Bracket) {
+			t.Fatalf("Test %d Expect: %v %#v, got %v %#v", i, d.ids, d.bracket, cv.IDs, cv.Bracket)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	cv, err := Parse(`/rule add close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY,h1)<30 "spread" repeat`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect := "close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY, h1) < 30"
+	if (cv.Action != ActionAdd) || (cv.Value.Kind != db.KindRule) || (cv.Value.Key != "EURUSD") || (cv.Value.Rule.Expr != expect) {
+		t.Fatalf("Unexpected value: %#v", cv.Value)
+	}
+	if (cv.Value.Note != "spread") || !cv.Value.Repeat {
+		t.Fatalf("Unexpected annotations: %#v", cv.Value)
+	}
+	if cv, err := Parse("/rule del 12"); (err != nil) || (cv.Action != ActionDelete) || (cv.Value.ID != 12) {
+		t.Fatalf("Unexpected delete: %#v, %v", cv, err)
+	}
+	for _, msg := range []string{"/rule", "/rule ls"} {
+		if cv, err := Parse(msg); (err != nil) || (cv.Action != ActionList) {
+			t.Fatalf("Unexpected list: %q. %#v, %v", msg, cv, err)
+		}
+	}
+	for _, msg := range []string{"/rule add close(EURUSD)", "/rule add", "/rule ls repeat", "/rule del x", "/rule add 1 > 0", "/rule add abs(-1) > 0"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+}
+
+func TestParseOptionBoundary(t *testing.T) {
+	// keyword of option can be a part of rest argument, options start after it
+	spec := commandSpec{
+		command: Rule,
+		forms: []form{{
+			args: []argSpec{textArg("TEXT")},
+			build: func(a args) (*CommandValue, error) {
+				return &CommandValue{Value: &db.Value{Note: a.str("TEXT")}}, nil
+			},
+		}},
+		options: valueOptions,
+	}
+	type tableData struct {
+		msg      string
+		note     string
+		maxCount uint
+	}
+
+	data := []tableData{
+		{msg: "note max three max 2", note: "note max three", maxCount: 2},
+		{msg: "note repeat max 2", note: "note", maxCount: 2},
+		{msg: "note max three", note: "note max three"},
+	}
+	for i, d := range data {
+		tokens, err := tokenize(d.msg)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		cv, err := spec.parse(tokens)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Note != d.note) || (cv.Value.MaxCount != d.maxCount) {
+			t.Fatalf("Test %d Expect: %q %d, got %q %d", i, d.note, d.maxCount, cv.Value.Note, cv.Value.MaxCount)
+		}
+	}
+	if _, err := Parse("/add EURUSD > 1.2 max x"); (err == nil) || !strings.Contains(err.Error(), "max") {
+		t.Fatalf("Expect error of option, got %v", err)
+	}
+}
+
+func TestParseComposite(t *testing.T) {
+	type tableData struct {
+		msg    string
+		key    string
+		vt     db.ValueType
+		expect float64
+	}
+
+	data := []tableData{
+		{msg: "/add EURUSD-GBPUSD < -0.15", key: "EURUSD-GBPUSD", vt: db.AboveCurrent, expect: -0.15},
+		{msg: "/add audusd/nzdusd 1.075", key: "AUDUSD/NZDUSD", expect: 1.075},
+		{msg: "/add fiber-cable > 0.1", key: "EURUSD-GBPUSD", vt: db.BelowCurrent, expect: 0.1},
+		{msg: "/range EURUSD-GBPUSD 0.1 0.2", key: "EURUSD-GBPUSD", expect: 0.1},
+		{msg: "/ls EURUSD-GBPUSD", key: "EURUSD-GBPUSD"},
+		{msg: "/del fiber/cable", key: "EURUSD/GBPUSD", expect: NoValue},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Key != d.key) || (cv.Value.Type != d.vt && cv.Value.Kind == db.KindLevel) || (cv.Value.Value != d.expect) {
+			t.Fatalf("Test %d Unexpected value: %#v", i, cv.Value)
+		}
+	}
+	if _, err := Parse("/add EURUSD < -0.15"); err == nil {
+		t.Fatal("Expect error for negative level of symbol")
+	}
+}
+
+func TestParseConfirm(t *testing.T) {
+	cv, err := Parse("/add EURUSD > 1.2 close h1 repeat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (cv.Value.Confirm == nil) || (cv.Value.Confirm.Timeframe != db.TimeframeHour) || !cv.Value.Repeat {
+		t.Fatalf("Unexpected value: %#v", cv.Value)
+	}
+	for _, msg := range []string{"/add EURUSD > 1.2 close m5", "/add EURUSD > 1.2 close", "/pct EURUSD +1% close d1"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+}
+
+func TestParseTouch(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect *db.Touch
+	}
+
+	data := []tableData{
+		{msg: "/touch EURUSD 1.2 3", expect: &db.Touch{Mode: db.TouchCount, Target: 3, Tolerance: DefaultTolerance}},
+		{msg: "/touch EURUSD 1.2 2 tol 50 repeat", expect: &db.Touch{Mode: db.TouchCount, Target: 2, Tolerance: 50}},
+		{msg: "/retest EURUSD 1.2 tol 10", expect: &db.Touch{Mode: db.TouchRetest, Tolerance: 10}},
+		{msg: "/touch EURUSD 1.2 0", expect: nil},
+		{msg: "/touch EURUSD 1.2", expect: nil},
+		{msg: "/retest EURUSD 1.2 tol -5", expect: nil},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.expect == nil {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv.Value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Kind != db.KindTouch) || (cv.Value.Value != 1.2) || !reflect.DeepEqual(d.expect, cv.Value.Touch) {
+			t.Fatalf("Test %d Expect: %#v, got %#v", i, d.expect, cv.Value.Touch)
+		}
+	}
+}
+
+func TestParseProximity(t *testing.T) {
+	type tableData struct {
+		msg    string
+		expect db.Offset
+	}
+
+	data := []tableData{
+		{msg: "/add EURUSD > 1.2 near 30p", expect: db.Offset{Amount: 30, Unit: db.OffsetPoints}},
+		{msg: "/add EURUSD 1.2 near 20%adr repeat", expect: db.Offset{Amount: 20, Unit: db.OffsetADR}},
+		{msg: "/grid EURUSD 1.1 1.2 step 100 near 0.5atr", expect: db.Offset{Amount: 0.5, Unit: db.OffsetATR}},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Proximity == nil) || (cv.Value.Proximity.Distance != d.expect) {
+			t.Fatalf("Test %d Expect: %v, got %#v", i, d.expect, cv.Value.Proximity)
+		}
+	}
+	for _, msg := range []string{"/add EURUSD > 1.2 near", "/add EURUSD > 1.2 near -30p", "/pct EURUSD +1% near 30p"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+	cv, err := Parse("/add EURUSD +20%adr")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (cv.Relative == nil) || (*cv.Relative != db.Offset{Amount: 20, Unit: db.OffsetADR}) {
+		t.Fatalf("Unexpected offset: %#v", cv.Relative)
+	}
+}
+
+func TestParseDynamic(t *testing.T) {
+	type tableData struct {
+		msg   string
+		vt    db.ValueType
+		level db.DynamicLevel
+	}
+
+	data := []tableData{
+		{msg: "/add EURUSD > PDH", vt: db.BelowCurrent, level: db.DynamicPDH},
+		{msg: "/add GBPUSD < wo repeat", vt: db.AboveCurrent, level: db.DynamicWO},
+		{msg: "/add cable x DO", vt: db.CrossAny, level: db.DynamicDO},
+		{msg: "/add USDJPY pdl", vt: db.CrossAny, level: db.DynamicPDL},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Value.Dynamic == nil) || (cv.Value.Dynamic.Level != d.level) || (cv.Value.Type != d.vt) {
+			t.Fatalf("Test %d Expect: %q %q, got %#v", i, d.vt, d.level, cv.Value)
+		}
+	}
+	if _, err := Parse("/add EURUSD > PWH"); err == nil {
+		t.Fatalf("Expect error for unsupported level")
+	}
+}
+
+func TestParseSnooze(t *testing.T) {
+	current := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	type tableData struct {
+		msg    string
+		id     uint64
+		key    string
+		until  time.Time
+		action string
+		err    bool
+	}
+
+	data := []tableData{
+		{msg: "/snooze 12 2h", id: 12, until: current.Add(2 * time.Hour)},
+		{msg: "/snooze #12 off", id: 12},
+		{msg: "/snooze", action: ActionList},
+		{msg: "/mute USDJPY 1d", key: "USDJPY", until: current.Add(24 * time.Hour)},
+		{msg: "/mute usdjpy 2021-06-03", key: "USDJPY", until: time.Date(2021, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{msg: "/mute cable off", key: "GBPUSD"},
+		{msg: "/mute", action: ActionList},
+		{msg: "/snooze 12", err: true},
+		{msg: "/snooze 12 2021-05-03", err: true},
+		{msg: "/mute USDJPY", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Action != d.action) || !cv.Until.Equal(d.until) {
+			t.Fatalf("Test %d Expect: %q %v, got %q %v", i, d.action, d.until, cv.Action, cv.Until)
+		}
+		if (d.action == "") && ((cv.Value.ID != d.id) || (cv.Value.Key != d.key)) {
+			t.Fatalf("Test %d Expect: %d %q, got %d %q", i, d.id, d.key, cv.Value.ID, cv.Value.Key)
+		}
+	}
+}
+
+func TestParseQuiet(t *testing.T) {
+	type tableData struct {
+		msg    string
+		quiet  *db.QuietHours
+		action string
+		err    bool
+	}
+
+	data := []tableData{
+		{msg: "/quiet 22:00 07:00", quiet: &db.QuietHours{From: 22 * 60, To: 7 * 60, Mode: db.QuietDigest}},
+		{msg: "/quiet 12:15 13 SILENT", quiet: &db.QuietHours{From: 12*60 + 15, To: 13 * 60, Mode: db.QuietSilent}},
+		{msg: "/quiet off", action: ActionDelete},
+		{msg: "/quiet", action: ActionList},
+		{msg: "/quiet 22:00 22:00", err: true},
+		{msg: "/quiet 25:00 07:00", err: true},
+		{msg: "/quiet 22:00 07:00 loud", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Action != d.action) || ((d.quiet != nil) && ((cv.Quiet == nil) || (*cv.Quiet != *d.quiet))) {
+			t.Fatalf("Test %d Expect: %q %v, got %q %v", i, d.action, d.quiet, cv.Action, cv.Quiet)
+		}
+	}
+
+	cv, err := Parse("/tz Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cv.Timezone != "Europe/Berlin" {
+		t.Fatalf("Unexpected timezone: %q", cv.Timezone)
+	}
+	for _, msg := range []string{"/tz Mars/Olympus", "/tz local"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	type tableData struct {
+		msg    string
+		window time.Duration
+		action string
+		err    bool
+	}
+
+	data := []tableData{
+		{msg: "/digest 30s", window: 30 * time.Second},
+		{msg: "/digest 5m", window: 5 * time.Minute},
+		{msg: "/digest off", action: ActionDelete},
+		{msg: "/digest", action: ActionList},
+		{msg: "/digest 2h", err: true},
+		{msg: "/digest 0s", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Window != d.window) || (cv.Action != d.action) {
+			t.Fatalf("Test %d Expect: %v %q, got %v %q", i, d.window, d.action, cv.Window, cv.Action)
+		}
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	cv, err := Parse("/add EURUSD > 1.2 priority HIGH repeat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (cv.Value.Priority != db.PriorityHigh) || !cv.Value.Repeat {
+		t.Fatalf("Unexpected value: %#v", cv.Value)
+	}
+	cv, err = Parse("/priority momentum low")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (cv.Kind != db.NotificationMomentum) || (cv.Priority != db.PriorityLow) {
+		t.Fatalf("Unexpected priority: %q %q", cv.Kind, cv.Priority)
+	}
+	cv, err = Parse("/ack 3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (len(cv.IDs) != 1) || (cv.IDs[0] != 3) {
+		t.Fatalf("Unexpected IDs: %v", cv.IDs)
+	}
+	for _, msg := range []string{"/add EURUSD > 1.2 priority urgent", "/priority trade high", "/priority level"} {
+		if _, err := Parse(msg); err == nil {
+			t.Fatalf("Expect error for: %q", msg)
+		}
+	}
+}
+
+func TestParseMomentum(t *testing.T) {
+	type tableData struct {
+		msg    string
+		rule   *db.MomentumRule
+		action string
+		err    bool
+	}
+
+	data := []tableData{
+		{msg: "/momentum on"},
+		{msg: "/momentum off", action: ActionDelete},
+		{msg: "/momentum", action: ActionList},
+		{msg: "/momentum eurusd 15m 80", rule: &db.MomentumRule{Symbol: "EURUSD", Window: 15 * time.Minute, Points: 80}},
+		{msg: "/momentum * 1h 200", rule: &db.MomentumRule{Symbol: db.MomentumAll, Window: time.Hour, Points: 200}},
+		{msg: "/momentum * 5m off", rule: &db.MomentumRule{Symbol: db.MomentumAll, Window: 5 * time.Minute}, action: ActionDelete},
+		{msg: "/momentum EURUSD 10m 80", err: true},
+		{msg: "/momentum EURUSD 5m 0", err: true},
+	}
+	for i, d := range data {
+		cv, err := Parse(d.msg)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %#v", i, cv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (cv.Action != d.action) || !reflect.DeepEqual(cv.MomentumRule, d.rule) {
+			t.Fatalf("Test %d Expect: %v %q, got %v %q", i, d.rule, d.action, cv.MomentumRule, cv.Action)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/rules"
+)
+
+// argSpec describe positional argument of command.
+type argSpec struct {
+	name  string
+	parse func(tok token) (interface{}, error)
+	// rest argument is the last one and takes all remaining tokens as single token.
+	rest bool
+}
+
+// form is one of supported argument lists of command.
+type form struct {
+	title   string
+	args    []argSpec
+	example string
+	build   func(a args) (*CommandValue, error)
+}
+
+// optionSpec is keyword with arguments which can follow any form of command, e.g. gtd 2021-06-01.
+type optionSpec struct {
+	keyword string
+	args    []argSpec
+	apply   func(cv *CommandValue, a args) error
+}
+
+type commandSpec struct {
+	command CommandType
+	aliases []string
+	title   string
+	forms   []form
+	options []optionSpec
+	// annotated command accepts "quoted note" and #tags at any position.
+	annotated bool
+	notes     []string
+}
+
+// args is parsed arguments by name.
+type args map[string]interface{}
+
+func (a args) str(name string) string {
+	v, _ := a[name].(string)
+
+	return v
+}
+
+func (a args) float(name string) float64 {
+	v, _ := a[name].(float64)
+
+	return v
+}
+
+func (a args) int(name string) int64 {
+	v, _ := a[name].(int64)
+
+	return v
+}
+
+func (a args) id(name string) uint64 {
+	v, _ := a[name].(uint64)
+
+	return v
+}
+
+func (a args) percent(name string) percentChange {
+	v, _ := a[name].(percentChange)
+
+	return v
+}
+
+func (a args) reference(name string) db.Reference {
+	v, _ := a[name].(db.Reference)
+
+	return v
+}
+
+func (a args) timeframe(name string) db.Timeframe {
+	v, _ := a[name].(db.Timeframe)
+
+	return v
+}
+
+func (a args) dynamic(name string) db.DynamicLevel {
+	v, _ := a[name].(db.DynamicLevel)
+
+	return v
+}
+
+func (a args) quietMode(name string) db.QuietMode {
+	v, _ := a[name].(db.QuietMode)
+
+	return v
+}
+
+func (a args) priority(name string) db.Priority {
+	v, _ := a[name].(db.Priority)
+
+	return v
+}
+
+func (a args) notificationKind(name string) db.NotificationKind {
+	v, _ := a[name].(db.NotificationKind)
+
+	return v
+}
+
+func (a args) rangeMode(name string) db.RangeMode {
+	v, _ := a[name].(db.RangeMode)
+
+	return v
+}
+
+func (a args) valueType(name string) db.ValueType {
+	v, _ := a[name].(db.ValueType)
+
+	return v
+}
+
+func (a args) offset(name string) *db.Offset {
+	v, _ := a[name].(*db.Offset)
+
+	return v
+}
+
+func (a args) ids(name string) []uint64 {
+	v, _ := a[name].([]uint64)
+
+	return v
+}
+
+func (a args) rule(name string) *rules.Rule {
+	v, _ := a[name].(*rules.Rule)
+
+	return v
+}
+
+func (a args) duration(name string) time.Duration {
+	v, _ := a[name].(time.Duration)
+
+	return v
+}
+
+func (a args) time(name string) time.Time {
+	v, _ := a[name].(time.Time)
+
+	return v
+}
+
+func (s commandSpec) usage() string {
+	lines := make([]string, 0, len(s.forms))
+	for _, f := range s.forms {
+		parts := []string{string(s.command)}
+		for _, a := range f.args {
+			parts = append(parts, a.name)
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+
+	if s.annotated {
+		lines = append(lines, `Annotations: ["note"] [#tag]`)
+	}
+	if len(s.options) > 0 {
+		opts := make([]string, 0, len(s.options))
+		for _, o := range s.options {
+			parts := []string{o.keyword}
+			for _, a := range o.args {
+				parts = append(parts, a.name)
+			}
+			opts = append(opts, "["+strings.Join(parts, " ")+"]")
+		}
+		lines = append(lines, "Options: "+strings.Join(opts, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (s commandSpec) findOption(tok token) *optionSpec {
+	if tok.quoted {
+		return nil
+	}
+	for i := range s.options {
+		if tok.lower() == s.options[i].keyword {
+			return &s.options[i]
+		}
+	}
+
+	return nil
+}
+
+// parse match tokens with command forms and options after them. First matched form wins.
+func (s commandSpec) parse(tokens []token) (*CommandValue, error) {
+	var note []string
+	var tags []string
+	if s.annotated {
+		var rest []token
+		for _, tok := range tokens {
+			switch {
+			case tok.quoted:
+				note = append(note, strings.TrimSpace(tok.text))
+			case isTag(tok.text):
+				tag := strings.ToLower(strings.TrimPrefix(tok.text, "#"))
+				if (tag == "") || (strings.IndexFunc(tag, isNotTagRune) >= 0) {
+					return nil, &UsageError{Command: s.command, Err: fmt.Errorf("Invalid tag: %q", tok.text), Usage: s.usage()}
+				}
+				tags = append(tags, tag)
+			default:
+				rest = append(rest, tok)
+			}
+		}
+		tokens = rest
+	}
+	cv, err := s.parseFormOptions(tokens)
+	if err != nil {
+		return nil, &UsageError{Command: s.command, Err: err, Usage: s.usage()}
+	}
+	cv.Command = s.command
+	if (cv.Value != nil) && s.annotated {
+		cv.Value.Note = strings.Join(note, " ")
+		cv.Value.Tags = tags
+		cv.NoteSet = len(note) > 0
+	}
+
+	return cv, nil
+}
+
+// parseFormOptions split tokens to form and options at option keyword.
+// Keyword can be a part of rest argument (e.g. expression), so the next keyword is tried if split fails.
+func (s commandSpec) parseFormOptions(tokens []token) (*CommandValue, error) {
+	var firstErr error
+	for pos := 0; pos <= len(tokens); pos++ {
+		if (pos < len(tokens)) && (s.findOption(tokens[pos]) == nil) {
+			continue
+		}
+		cv, err := s.parseForm(tokens[:pos])
+		if err == nil {
+			err = s.parseOptions(cv, tokens[pos:])
+		}
+		if err == nil {
+			return cv, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+func (s commandSpec) parseForm(tokens []token) (*CommandValue, error) {
+	var formErr error
+	for _, f := range s.forms {
+		ftokens := tokens
+		if last := len(f.args) - 1; (last >= 0) && f.args[last].rest && (len(tokens) > last) {
+			rest := make([]string, 0, len(tokens)-last)
+			for _, tok := range tokens[last:] {
+				rest = append(rest, tok.text)
+			}
+			ftokens = append(append([]token(nil), tokens[:last]...), token{text: strings.Join(rest, " ")})
+		}
+		if len(f.args) != len(ftokens) {
+			continue
+		}
+		a, err := parseArgs(f.args, ftokens)
+		if err != nil {
+			if formErr == nil {
+				formErr = err
+			}
+			continue
+		}
+
+		return f.build(a)
+	}
+	if formErr == nil {
+		formErr = errors.New("Unsupported command format")
+	}
+
+	return nil, formErr
+}
+
+func (s commandSpec) parseOptions(cv *CommandValue, tokens []token) error {
+	if (len(tokens) > 0) && (cv.Value == nil) {
+		return fmt.Errorf("Unexpected option: %q", tokens[0].text)
+	}
+	for len(tokens) > 0 {
+		o := s.findOption(tokens[0])
+		if o == nil {
+			return fmt.Errorf("Unsupported option: %q", tokens[0].text)
+		}
+		tokens = tokens[1:]
+		if len(tokens) < len(o.args) {
+			return fmt.Errorf("Not enough arguments for: %q", o.keyword)
+		}
+		a, err := parseArgs(o.args, tokens[:len(o.args)])
+		if err != nil {
+			return fmt.Errorf("%s: %w", o.keyword, err)
+		}
+		if err := o.apply(cv, a); err != nil {
+			return fmt.Errorf("%s: %w", o.keyword, err)
+		}
+		tokens = tokens[len(o.args):]
+	}
+
+	return nil
+}
+
+func parseArgs(specs []argSpec, tokens []token) (args, error) {
+	a := args{}
+	for i, spec := range specs {
+		v, err := spec.parse(tokens[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.name, err)
+		}
+		a[spec.name] = v
+	}
+
+	return a, nil
+}
+
+// specs is supported commands by family in order of help.
+var specs = joinSpecs(valueSpecs, levelSpecs, settingSpecs, ruleSpecs, helpSpecs)
+
+// joinSpecs join families of commands.
+func joinSpecs(families ...[]commandSpec) []commandSpec {
+	var res []commandSpec
+	for _, f := range families {
+		res = append(res, f...)
+	}
+
+	return res
+}
+
+// helpSpecs is help command.
+var helpSpecs = []commandSpec{
+	{
+		command: Help,
+		aliases: []string{"/h", "/start"},
+		title:   "Help",
+		forms: []form{
+			{
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{}, nil
+				},
+			},
+		},
+	},
+}
@@ -0,0 +1,630 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/rules"
+)
+
+func keywordArg(word string) argSpec {
+	return argSpec{
+		name: word,
+		parse: func(tok token) (interface{}, error) {
+			if tok.lower() != word {
+				return nil, fmt.Errorf("Expected: %q", word)
+			}
+
+			return word, nil
+		},
+	}
+}
+
+// symbolArg is symbol or composite of two symbols: EURUSD, EURUSD-GBPUSD, AUDUSD/NZDUSD.
+func symbolArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			s := quoter.NormalizeSymbol(tok.text)
+			if _, ok := quoter.ParseComposite(s); ok {
+				return s, nil
+			}
+			if (s == "") || (strings.IndexFunc(s, isNotLetter) >= 0) {
+				return nil, fmt.Errorf("Invalid symbol: %q", tok.text)
+			}
+
+			return s, nil
+		},
+	}
+}
+
+// compositeArg is spread or ratio of two symbols.
+func compositeArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			c, ok := quoter.ParseComposite(tok.text)
+			if !ok {
+				return nil, fmt.Errorf("Invalid spread or ratio: %q", tok.text)
+			}
+
+			return c.String(), nil
+		},
+	}
+}
+
+// filterArg is part of symbol, spread or ratio of symbols, or AnySymbol.
+func filterArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			if tok.text == AnySymbol {
+				return AnySymbol, nil
+			}
+			if c, ok := quoter.ParseComposite(tok.text); ok {
+				return c.String(), nil
+			}
+			s := quoter.NormalizeSymbol(tok.text)
+			if (s == "") || (strings.IndexFunc(s, isNotLetter) >= 0) {
+				return nil, fmt.Errorf("Invalid symbol filter: %q", tok.text)
+			}
+
+			return s, nil
+		},
+	}
+}
+
+func directionArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			return db.ValueTypeFromString(tok.text)
+		},
+	}
+}
+
+// levelArg is positive price without sign.
+func levelArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			if isRelative(tok.text) {
+				return nil, fmt.Errorf("Unexpected sign: %q", tok.text)
+			}
+			v, err := parseFloat(tok.text)
+			if err != nil {
+				return nil, err
+			}
+			if v <= 0 {
+				return nil, errors.New("Must be > 0")
+			}
+
+			return v, nil
+		},
+	}
+}
+
+// dynamicArg is named level which is resolved every session: PDH, PDL, DO, WO.
+func dynamicArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch d := db.DynamicLevel(tok.lower()); d {
+			case db.DynamicPDH, db.DynamicPDL, db.DynamicDO, db.DynamicWO:
+				return d, nil
+			}
+
+			return nil, fmt.Errorf("Unsupported level: %q", tok.text)
+		},
+	}
+}
+
+// timezoneArg is IANA name of timezone: Europe/Berlin, UTC.
+func timezoneArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			loc, err := time.LoadLocation(tok.text)
+			if (err != nil) || (tok.text == "") || strings.EqualFold(tok.text, "local") {
+				return nil, fmt.Errorf("Unknown timezone: %q", tok.text)
+			}
+
+			return loc.String(), nil
+		},
+	}
+}
+
+// clockArg is time of day as minutes since midnight: 22:00, 7.
+func clockArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			txt := tok.text
+			if !strings.Contains(txt, ":") {
+				txt += ":00"
+			}
+			t, err := time.Parse("15:04", txt)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid time of day: %q", tok.text)
+			}
+
+			return int64(t.Hour()*60 + t.Minute()), nil
+		},
+	}
+}
+
+func quietModeArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch m := db.QuietMode(tok.lower()); m {
+			case db.QuietDigest, db.QuietSilent:
+				return m, nil
+			}
+
+			return nil, fmt.Errorf("Unsupported mode: %q", tok.text)
+		},
+	}
+}
+
+func priorityArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch p := db.Priority(tok.lower()); p {
+			case db.PriorityLow, db.PriorityNormal, db.PriorityHigh:
+				return p, nil
+			}
+
+			return nil, fmt.Errorf("Unsupported priority: %q", tok.text)
+		},
+	}
+}
+
+func notificationKindArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch k := db.NotificationKind(tok.lower()); k {
+			case db.NotificationLevel, db.NotificationMomentum, db.NotificationPattern:
+				return k, nil
+			}
+
+			return nil, fmt.Errorf("Unsupported notification: %q", tok.text)
+		},
+	}
+}
+
+// momentumSymbolArg is symbol or db.MomentumAll.
+func momentumSymbolArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			if tok.text == db.MomentumAll {
+				return db.MomentumAll, nil
+			}
+			s := quoter.NormalizeSymbol(tok.text)
+			if (s == "") || (strings.IndexFunc(s, isNotLetter) >= 0) {
+				return nil, fmt.Errorf("Invalid symbol: %q", tok.text)
+			}
+
+			return s, nil
+		},
+	}
+}
+
+func momentumWindowArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			w, err := parseDuration(tok.lower())
+			if err != nil {
+				return nil, err
+			}
+			if !db.IsMomentumWindow(w) {
+				return nil, fmt.Errorf("Unsupported window: %q", tok.text)
+			}
+
+			return w, nil
+		},
+	}
+}
+
+// priceArg is price with optional sign, spread of symbols can be negative.
+func priceArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			return parseFloat(tok.text)
+		},
+	}
+}
+
+func offsetArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			if !isRelative(tok.text) {
+				return nil, fmt.Errorf("Offset must start with + or -: %q", tok.text)
+			}
+
+			return parseOffset(tok.lower())
+		},
+	}
+}
+
+// idArg is ID of stored value.
+func idArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			v, err := strconv.ParseUint(strings.Trim(tok.text, "#[]"), 10, 64)
+			if (err != nil) || (v == 0) {
+				return nil, fmt.Errorf("Invalid ID: %q", tok.text)
+			}
+
+			return v, nil
+		},
+	}
+}
+
+// idsArg is the rest of command as list of IDs.
+func idsArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		rest: true,
+		parse: func(tok token) (interface{}, error) {
+			fields := strings.Fields(tok.text)
+			ids := make([]uint64, 0, len(fields))
+			seen := map[uint64]bool{}
+			for _, f := range fields {
+				v, err := strconv.ParseUint(strings.Trim(f, "#[],"), 10, 64)
+				if (err != nil) || (v == 0) {
+					return nil, fmt.Errorf("Invalid ID: %q", f)
+				}
+				if seen[v] {
+					continue
+				}
+				seen[v] = true
+				ids = append(ids, v)
+			}
+			if len(ids) < 2 {
+				return nil, errors.New("At least 2 IDs are required")
+			}
+
+			return ids, nil
+		},
+	}
+}
+
+// distanceArg is offset without sign: 50p, 0.5%, 2atr.
+func distanceArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			if isRelative(tok.text) {
+				return nil, fmt.Errorf("Unexpected sign: %q", tok.text)
+			}
+
+			return parseOffset(tok.lower())
+		},
+	}
+}
+
+// sideArg is long (stop below price) or short (stop above price).
+func sideArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch tok.lower() {
+			case "long":
+				return db.AboveCurrent, nil
+			case "short":
+				return db.BelowCurrent, nil
+			}
+
+			return nil, fmt.Errorf("Expected long or short: %q", tok.text)
+		},
+	}
+}
+
+func rangeModeArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch m := db.RangeMode(tok.lower()); m {
+			case db.RangeExit, db.RangeEnter:
+				return m, nil
+			}
+
+			return nil, fmt.Errorf("Expected exit or enter: %q", tok.text)
+		},
+	}
+}
+
+// ruleArg is the rest of command as checked expression.
+func ruleArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		rest: true,
+		parse: func(tok token) (interface{}, error) {
+			r, err := rules.Parse(tok.text)
+			if err != nil {
+				return nil, err
+			}
+			// symbol of rule is key of stored value
+			if len(r.Symbols()) == 0 {
+				return nil, errors.New("Expression must use at least one symbol")
+			}
+
+			return r, nil
+		},
+	}
+}
+
+func timeframeArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch tf := db.Timeframe(tok.lower()); tf {
+			case db.TimeframeHour, db.TimeframeDay:
+				return tf, nil
+			}
+
+			return nil, fmt.Errorf("Expected h1 or d1: %q", tok.text)
+		},
+	}
+}
+
+// textArg is the rest of command as is.
+func textArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		rest: true,
+		parse: func(tok token) (interface{}, error) {
+			return tok.text, nil
+		},
+	}
+}
+
+// percentArg is percent with optional sign and % suffix, sign is direction of change.
+func percentArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			txt := strings.TrimSuffix(tok.text, "%")
+			vt := db.CrossAny
+			if strings.HasPrefix(txt, "+") {
+				vt = db.BelowCurrent
+			} else if strings.HasPrefix(txt, "-") {
+				vt = db.AboveCurrent
+			}
+			v, err := parseFloat(strings.TrimLeft(txt, "+-"))
+			if err != nil {
+				return nil, err
+			}
+			if v <= 0 {
+				return nil, errors.New("Must be > 0")
+			}
+
+			return percentChange{Percent: v, Type: vt}, nil
+		},
+	}
+}
+
+type percentChange struct {
+	Percent float64
+	Type    db.ValueType
+}
+
+func referenceArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			switch r := db.Reference(tok.lower()); r {
+			case db.ReferenceSet, db.ReferenceOpen:
+				return r, nil
+			}
+
+			return nil, fmt.Errorf("Unsupported reference: %q", tok.text)
+		},
+	}
+}
+
+// pointsArg is positive integer number of points.
+func pointsArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			rawVal := strings.ReplaceAll(tok.text, ",", ".")
+			v, err := strconv.ParseInt(rawVal, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Can't parse points: %q. %w", rawVal, err)
+			}
+			if v <= 0 {
+				return nil, errors.New("Must be > 0")
+			}
+
+			return v, nil
+		},
+	}
+}
+
+// timeArg is date, date with time in UTC or duration from now: 2021-06-01, 2021-06-01T15:00, 4h, 3d.
+func timeArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			txt := tok.lower()
+			for _, layout := range []string{"2006-01-02", "2006-01-02t15:04"} {
+				if t, err := time.Parse(layout, txt); err == nil {
+					return t, nil
+				}
+			}
+			d, err := parseDuration(txt)
+			if err != nil {
+				return nil, fmt.Errorf("Unsupported time: %q", tok.text)
+			}
+
+			return now().Add(d), nil
+		},
+	}
+}
+
+// futureTime return error if t is not in future.
+func futureTime(t time.Time) (time.Time, error) {
+	if !t.After(now()) {
+		return t, errors.New("Time must be in future")
+	}
+
+	return t, nil
+}
+
+// parseDuration support days in addition to time.ParseDuration units.
+func parseDuration(txt string) (time.Duration, error) {
+	var d time.Duration
+	if strings.HasSuffix(txt, "d") {
+		days, err := strconv.ParseUint(strings.TrimSuffix(txt, "d"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(txt)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, errors.New("Duration must be > 0")
+	}
+
+	return d, nil
+}
+
+func durationArg(name string) argSpec {
+	return argSpec{
+		name: name,
+		parse: func(tok token) (interface{}, error) {
+			return parseDuration(tok.lower())
+		},
+	}
+}
+
+// isTag return true for #tag, but not for #12 which is ID.
+func isTag(txt string) bool {
+	return strings.HasPrefix(txt, "#") && (strings.IndexFunc(txt, unicode.IsDigit) != 1)
+}
+
+func isNotTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && (r != '_')
+}
+
+func isNotLetter(r rune) bool {
+	return (r < 'A') || (r > 'Z')
+}
+
+func isRelative(txt string) bool {
+	return strings.HasPrefix(txt, "+") || strings.HasPrefix(txt, "-")
+}
+
+func parseFloat(txt string) (float64, error) {
+	rawVal := strings.ReplaceAll(txt, ",", ".")
+	v, err := strconv.ParseFloat(rawVal, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Can't parse value: %q. %w", rawVal, err)
+	}
+
+	return v, nil
+}
+
+// parseOffset parse relative level: +50p, -0.3%, +2atr, +20%adr.
+func parseOffset(txt string) (*db.Offset, error) {
+	var unit db.OffsetUnit
+	for _, u := range []db.OffsetUnit{db.OffsetADR, db.OffsetATR, db.OffsetPercent, db.OffsetPoints} {
+		if strings.HasSuffix(txt, string(u)) {
+			unit = u
+			break
+		}
+	}
+	if unit == "" {
+		return nil, fmt.Errorf("Unsupported offset unit: %q", txt)
+	}
+	v, err := parseFloat(strings.TrimSuffix(txt, string(unit)))
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse offset: %w", err)
+	}
+	if v == 0 {
+		return nil, errors.New("Offset must not be 0")
+	}
+	if (unit == db.OffsetPoints) && (v != math.Trunc(v)) {
+		return nil, errors.New("Points must be integer")
+	}
+
+	return &db.Offset{Amount: v, Unit: unit}, nil
+}
+
+func newValue(symbol string, vt db.ValueType, level float64) *db.Value {
+	return &db.Value{
+		Key:       symbol,
+		Value:     level,
+		Type:      vt,
+		Precision: quoter.GetPrecision(symbol),
+	}
+}
+
+func newDynamicValue(symbol string, vt db.ValueType, level db.DynamicLevel) *CommandValue {
+	v := newValue(symbol, vt, 0)
+	v.Dynamic = &db.Dynamic{Level: level}
+
+	return &CommandValue{Value: v}
+}
+
+func newQuietHours(from int64, to int64, mode db.QuietMode) (*CommandValue, error) {
+	if from == to {
+		return nil, errors.New("Empty quiet hours")
+	}
+
+	return &CommandValue{Quiet: &db.QuietHours{From: int(from), To: int(to), Mode: mode}}, nil
+}
+
+func newRangeValue(symbol string, low float64, high float64, mode db.RangeMode) (*CommandValue, error) {
+	if low > high {
+		low, high = high, low
+	}
+	if low == high {
+		return nil, fmt.Errorf("Empty range: %v", low)
+	}
+	v := newValue(symbol, db.CrossAny, low)
+	v.Kind = db.KindRange
+	v.Range = &db.Range{Low: low, High: high, Mode: mode}
+
+	return &CommandValue{Value: v}, nil
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+func newTouchValue(symbol string, level float64, mode db.TouchMode, target int64) *CommandValue {
+	v := newValue(symbol, db.CrossAny, level)
+	v.Kind = db.KindTouch
+	v.Touch = &db.Touch{Mode: mode, Target: uint(target), Tolerance: DefaultTolerance}
+
+	return &CommandValue{Value: v}
+}
+
+func newPercentValue(symbol string, pc percentChange, ref db.Reference) *CommandValue {
+	v := newValue(symbol, pc.Type, 0)
+	v.Kind = db.KindPercent
+	v.Percent = &db.PercentChange{Percent: pc.Percent, Reference: ref}
+
+	return &CommandValue{Value: v}
+}
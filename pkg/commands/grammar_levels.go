@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+)
+
+// levelSpecs is commands which add several levels or link them.
+var levelSpecs = []commandSpec{
+	{
+		command: Grid,
+		title:   "Grid",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("FROM"), levelArg("TO"), keywordArg("step"), pointsArg("POINTS")},
+				example: "EURUSD 1.1000 1.1200 step 20",
+				build: func(a args) (*CommandValue, error) {
+					k := a.str("SYMBOL")
+					g := &GridSpec{From: a.float("FROM"), To: a.float("TO"), Step: a.int("POINTS")}
+					if math.Abs(g.To-g.From)/quoter.FromPoints(k, g.Step) >= MaxGridLevels {
+						return nil, fmt.Errorf("Too many grid levels, max: %d", MaxGridLevels)
+					}
+
+					return &CommandValue{Value: &db.Value{Key: k, Precision: quoter.GetPrecision(k)}, Grid: g}, nil
+				},
+			},
+		},
+		options:   levelOptions,
+		annotated: true,
+	},
+	{
+		command: Touch,
+		title:   "Touch",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LEVEL"), pointsArg("COUNT")},
+				example: "EURUSD 1.2000 3 tol 30",
+				build: func(a args) (*CommandValue, error) {
+					return newTouchValue(a.str("SYMBOL"), a.float("LEVEL"), db.TouchCount, a.int("COUNT")), nil
+				},
+			},
+		},
+		options:   touchOptions,
+		annotated: true,
+		notes: []string{
+			fmt.Sprintf("Alert on COUNT touch of level, touch is price within tolerance (default: %d points)", DefaultTolerance),
+		},
+	},
+	{
+		command: Retest,
+		title:   "Retest",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LEVEL")},
+				example: "EURUSD 1.2000 tol 30",
+				build: func(a args) (*CommandValue, error) {
+					return newTouchValue(a.str("SYMBOL"), a.float("LEVEL"), db.TouchRetest, 0), nil
+				},
+			},
+		},
+		options:   touchOptions,
+		annotated: true,
+		notes: []string{
+			"Alert when price returns to level after breakout",
+		},
+	},
+	{
+		command: Bracket,
+		title:   "Bracket",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), distanceArg("DISTANCE")},
+				example: "EURUSD 50p",
+				build: func(a args) (*CommandValue, error) {
+					k := a.str("SYMBOL")
+					b := &BracketSpec{Distance: a.offset("DISTANCE")}
+
+					return &CommandValue{Value: &db.Value{Key: k, Precision: quoter.GetPrecision(k)}, Bracket: b}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LOW"), levelArg("HIGH")},
+				example: "EURUSD 1.0950 1.1100",
+				build: func(a args) (*CommandValue, error) {
+					k := a.str("SYMBOL")
+					b := &BracketSpec{Low: math.Min(a.float("LOW"), a.float("HIGH")), High: math.Max(a.float("LOW"), a.float("HIGH"))}
+					if b.Low == b.High {
+						return nil, fmt.Errorf("Empty bracket: %v", b.Low)
+					}
+
+					return &CommandValue{Value: &db.Value{Key: k, Precision: quoter.GetPrecision(k)}, Bracket: b}, nil
+				},
+			},
+		},
+		options:   levelOptions,
+		annotated: true,
+		notes: []string{
+			"Add linked levels above and below the current price, the first triggered one cancels the other",
+		},
+	},
+	{
+		command: Link,
+		aliases: []string{"/oco"},
+		title:   "Link",
+		forms: []form{
+			{
+				args:    []argSpec{idsArg("IDS")},
+				example: "12 14",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{IDs: a.ids("IDS")}, nil
+				},
+			},
+		},
+		notes: []string{
+			"Link alerts: when one of them is triggered or deleted the others are cancelled",
+		},
+	},
+	{
+		command: Unlink,
+		title:   "Unlink",
+		forms: []form{
+			{
+				args:    []argSpec{idArg("ID")},
+				example: "12",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{IDs: []uint64{a.id("ID")}}, nil
+				},
+			},
+		},
+	},
+}
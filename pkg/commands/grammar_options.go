@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"time"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+)
+
+// valueOptions is supported by all commands which add values.
+var valueOptions = []optionSpec{
+	{
+		keyword: string(db.GoodTillCancelled),
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.TimeInForce = db.GoodTillCancelled
+			cv.Value.ExpiresAt = time.Time{}
+
+			return nil
+		},
+	},
+	{
+		keyword: string(db.EndOfDay),
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.TimeInForce = db.EndOfDay
+			cv.Value.ExpiresAt = quoter.EndOfDay(now())
+
+			return nil
+		},
+	},
+	{
+		keyword: string(db.GoodTillDate),
+		args:    []argSpec{timeArg("TIME")},
+		apply: func(cv *CommandValue, a args) error {
+			t, err := futureTime(a.time("TIME"))
+			if err != nil {
+				return err
+			}
+			cv.Value.TimeInForce = db.GoodTillDate
+			cv.Value.ExpiresAt = t
+
+			return nil
+		},
+	},
+	{
+		keyword: "repeat",
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Repeat = true
+
+			return nil
+		},
+	},
+	{
+		keyword: "cooldown",
+		args:    []argSpec{durationArg("DURATION")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Repeat = true
+			cv.Value.Cooldown = a.duration("DURATION")
+
+			return nil
+		},
+	},
+	{
+		keyword: "max",
+		args:    []argSpec{pointsArg("COUNT")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Repeat = true
+			cv.Value.MaxCount = uint(a.int("COUNT"))
+
+			return nil
+		},
+	},
+	{
+		keyword: "rearm",
+		args:    []argSpec{pointsArg("POINTS")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Repeat = true
+			cv.Value.Rearm = a.int("POINTS")
+
+			return nil
+		},
+	},
+	{
+		keyword: "priority",
+		args:    []argSpec{priorityArg("PRIORITY")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Priority = a.priority("PRIORITY")
+
+			return nil
+		},
+	},
+}
+
+// levelOptions is supported by commands which add price levels.
+var levelOptions = append(
+	append([]optionSpec(nil), valueOptions...),
+	optionSpec{
+		keyword: "close",
+		args:    []argSpec{timeframeArg("TIMEFRAME")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Confirm = &db.Confirm{Timeframe: a.timeframe("TIMEFRAME")}
+
+			return nil
+		},
+	},
+	optionSpec{
+		keyword: "near",
+		args:    []argSpec{distanceArg("DISTANCE")},
+		apply: func(cv *CommandValue, a args) error {
+			cv.Value.Proximity = &db.Proximity{Distance: *a.offset("DISTANCE")}
+
+			return nil
+		},
+	},
+)
+
+// touchOptions is supported by commands which count touches of level.
+var touchOptions = append(append([]optionSpec(nil), valueOptions...), optionSpec{
+	keyword: "tol",
+	args:    []argSpec{pointsArg("POINTS")},
+	apply: func(cv *CommandValue, a args) error {
+		cv.Value.Touch.Tolerance = a.int("POINTS")
+
+		return nil
+	},
+})
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/rules"
+)
+
+// ruleSpecs is commands of rules of expressions.
+var ruleSpecs = []commandSpec{
+	{
+		command: Rule,
+		title:   "Rule",
+		forms: []form{
+			{
+				args:    []argSpec{keywordArg(ActionAdd), ruleArg("EXPRESSION")},
+				example: "add close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY, h1) < 30",
+				build: func(a args) (*CommandValue, error) {
+					r := a.rule("EXPRESSION")
+					v := newValue(r.Symbols()[0], "", 0)
+					v.Kind = db.KindRule
+					v.Rule = &db.Rule{Expr: r.String()}
+
+					return &CommandValue{Value: v, Action: ActionAdd}, nil
+				},
+			},
+			{
+				args:    []argSpec{keywordArg(ActionList)},
+				example: "ls",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+			{
+				args:    []argSpec{keywordArg(ActionDelete), idArg("ID")},
+				example: "del 12",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}, Action: ActionDelete}, nil
+				},
+			},
+			{
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		options:   valueOptions,
+		annotated: true,
+		notes:     append([]string{"Alert when expression becomes true"}, rules.Usage()...),
+	},
+}
@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"fmt"
+
+	"fx_alert/pkg/db"
+)
+
+// settingSpecs is commands of user settings and notifications.
+var settingSpecs = []commandSpec{
+	{
+		command: Snooze,
+		title:   "Snooze",
+		forms: []form{
+			{
+				args:    []argSpec{idArg("ID"), timeArg("TIME")},
+				example: "12 2h",
+				build: func(a args) (*CommandValue, error) {
+					t, err := futureTime(a.time("TIME"))
+					if err != nil {
+						return nil, err
+					}
+
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}, Until: t}, nil
+				},
+			},
+			{
+				title:   "Cancel snooze",
+				args:    []argSpec{idArg("ID"), keywordArg("off")},
+				example: "12 off",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}}, nil
+				},
+			},
+			{
+				title: "Snoozed and muted",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{"Snoozed alert is checked, but not sent till the end of snooze"},
+	},
+	{
+		command: Mute,
+		title:   "Mute",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), timeArg("TIME")},
+				example: "USDJPY 1d",
+				build: func(a args) (*CommandValue, error) {
+					t, err := futureTime(a.time("TIME"))
+					if err != nil {
+						return nil, err
+					}
+
+					return &CommandValue{Value: &db.Value{Key: a.str("SYMBOL")}, Until: t}, nil
+				},
+			},
+			{
+				title:   "Unmute",
+				args:    []argSpec{symbolArg("SYMBOL"), keywordArg("off")},
+				example: "USDJPY off",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{Key: a.str("SYMBOL")}}, nil
+				},
+			},
+			{
+				title: "Snoozed and muted",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{"Momentum and patterns of muted symbol are not sent"},
+	},
+	{
+		command: Timezone,
+		title:   "Timezone",
+		forms: []form{
+			{
+				args:    []argSpec{timezoneArg("TIMEZONE")},
+				example: "Europe/Berlin",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Timezone: a.str("TIMEZONE")}, nil
+				},
+			},
+			{
+				title: "Current timezone",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{"Times are shown and quiet hours are checked in timezone, UTC is default"},
+	},
+	{
+		command: Quiet,
+		title:   "Quiet hours",
+		forms: []form{
+			{
+				args:    []argSpec{clockArg("FROM"), clockArg("TO")},
+				example: "22:00 07:00",
+				build: func(a args) (*CommandValue, error) {
+					return newQuietHours(a.int("FROM"), a.int("TO"), db.QuietDigest)
+				},
+			},
+			{
+				args:    []argSpec{clockArg("FROM"), clockArg("TO"), quietModeArg("MODE")},
+				example: "12:00 13:30 silent",
+				build: func(a args) (*CommandValue, error) {
+					return newQuietHours(a.int("FROM"), a.int("TO"), a.quietMode("MODE"))
+				},
+			},
+			{
+				title:   "Disable quiet hours",
+				args:    []argSpec{keywordArg("off")},
+				example: "off",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionDelete}, nil
+				},
+			},
+			{
+				title: "Current quiet hours",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{
+			"Modes: digest (default, notifications are sent after quiet hours), silent (sent without sound)",
+		},
+	},
+	{
+		command: Digest,
+		title:   "Digest",
+		forms: []form{
+			{
+				args:    []argSpec{durationArg("WINDOW")},
+				example: "30s",
+				build: func(a args) (*CommandValue, error) {
+					w := a.duration("WINDOW")
+					if w > MaxDigestWindow {
+						return nil, fmt.Errorf("Window must be <= %s", MaxDigestWindow)
+					}
+
+					return &CommandValue{Window: w}, nil
+				},
+			},
+			{
+				title:   "Disable digest",
+				args:    []argSpec{keywordArg("off")},
+				example: "off",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionDelete}, nil
+				},
+			},
+			{
+				title: "Current digest",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{"Alerts, momentum and patterns within window are sent as one message"},
+	},
+	{
+		command: Priority,
+		title:   "Priority",
+		forms: []form{
+			{
+				args:    []argSpec{notificationKindArg("NOTIFICATION"), priorityArg("PRIORITY")},
+				example: "momentum low",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Kind: a.notificationKind("NOTIFICATION"), Priority: a.priority("PRIORITY")}, nil
+				},
+			},
+			{
+				title: "Current priorities",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{
+			"Notifications: level, momentum, pattern",
+			"Priorities: low (silent), normal, high (repeated until Ack, not during quiet hours)",
+		},
+	},
+	{
+		command: Ack,
+		title:   "Acknowledge",
+		forms: []form{
+			{
+				args:    []argSpec{idArg("ID")},
+				example: "3",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{IDs: []uint64{a.id("ID")}}, nil
+				},
+			},
+			{
+				title: "Acknowledge all",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{}, nil
+				},
+			},
+		},
+		notes: []string{"High priority notification is repeated until it is acknowledged"},
+	},
+	{
+		command: Momentum,
+		title:   "Momentum",
+		forms: []form{
+			{
+				title:   "Enable momentum",
+				args:    []argSpec{keywordArg("on")},
+				example: "on",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{}, nil
+				},
+			},
+			{
+				title:   "Disable momentum",
+				args:    []argSpec{keywordArg("off")},
+				example: "off",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionDelete}, nil
+				},
+			},
+			{
+				args:    []argSpec{momentumSymbolArg("SYMBOL"), momentumWindowArg("WINDOW"), pointsArg("POINTS")},
+				example: "EURUSD 15m 80",
+				build: func(a args) (*CommandValue, error) {
+					r := db.MomentumRule{Symbol: a.str("SYMBOL"), Window: a.duration("WINDOW"), Points: a.int("POINTS")}
+
+					return &CommandValue{MomentumRule: &r}, nil
+				},
+			},
+			{
+				title:   "Remove rule",
+				args:    []argSpec{momentumSymbolArg("SYMBOL"), momentumWindowArg("WINDOW"), keywordArg("off")},
+				example: "* 1h off",
+				build: func(a args) (*CommandValue, error) {
+					r := db.MomentumRule{Symbol: a.str("SYMBOL"), Window: a.duration("WINDOW")}
+
+					return &CommandValue{MomentumRule: &r, Action: ActionDelete}, nil
+				},
+			},
+			{
+				title: "Current momentum",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Action: ActionList}, nil
+				},
+			},
+		},
+		notes: []string{
+			"Windows: 5m, 15m, 1h. SYMBOL * is rule for all symbols, rule of symbol overrides it",
+			"Momentum is enabled by default, adding rule enables it again",
+			"Without rules move of 50 points (500 for BTCUSD) in 5m is sent",
+		},
+	},
+}
@@ -0,0 +1,289 @@
+package commands
+
+import "fx_alert/pkg/db"
+
+// valueSpecs is commands which add, change and list values.
+var valueSpecs = []commandSpec{
+	{
+		command: AddValue,
+		aliases: []string{"/a"},
+		title:   "Add",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), directionArg("DIRECTION"), levelArg("LEVEL")},
+				example: "EURUSD > 1.2550",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: newValue(a.str("SYMBOL"), a.valueType("DIRECTION"), a.float("LEVEL"))}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), offsetArg("OFFSET")},
+				example: "EURUSD +50p",
+				build: func(a args) (*CommandValue, error) {
+					off := a.offset("OFFSET")
+					vt := db.BelowCurrent
+					if off.Amount < 0 {
+						vt = db.AboveCurrent
+					}
+
+					return &CommandValue{Value: newValue(a.str("SYMBOL"), vt, 0), Relative: off}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LEVEL")},
+				example: "EURUSD 1.2550",
+				build: func(a args) (*CommandValue, error) {
+					// without explicit type, direction will be inferred from the current quote
+					return &CommandValue{Value: newValue(a.str("SYMBOL"), "", a.float("LEVEL"))}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), directionArg("DIRECTION"), dynamicArg("NAMED")},
+				example: "EURUSD > PDH",
+				build: func(a args) (*CommandValue, error) {
+					return newDynamicValue(a.str("SYMBOL"), a.valueType("DIRECTION"), a.dynamic("NAMED")), nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), dynamicArg("NAMED")},
+				example: "GBPUSD WO",
+				build: func(a args) (*CommandValue, error) {
+					// level moves every day, so it can be on any side of price
+					return newDynamicValue(a.str("SYMBOL"), db.CrossAny, a.dynamic("NAMED")), nil
+				},
+			},
+			{
+				args:    []argSpec{compositeArg("SPREAD"), directionArg("DIRECTION"), priceArg("LEVEL")},
+				example: "EURUSD-GBPUSD < -0.1500",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: newValue(a.str("SPREAD"), a.valueType("DIRECTION"), a.float("LEVEL"))}, nil
+				},
+			},
+			{
+				args:    []argSpec{compositeArg("SPREAD"), priceArg("LEVEL")},
+				example: "AUDUSD/NZDUSD 1.0750",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: newValue(a.str("SPREAD"), "", a.float("LEVEL"))}, nil
+				},
+			},
+		},
+		options:   levelOptions,
+		annotated: true,
+		notes: []string{
+			`Note and tags: /add EURUSD > 1.2550 "weekly resistance" #tp`,
+			"Expiration: gtc (default), eod (end of UTC day), gtd 2021-06-01T15:00, gtd 4h, gtd 3d",
+			"Repeat: repeat, cooldown 30m, max 5, rearm 20 (points back from level before next alert)",
+			"Priority: priority low (silent), priority normal, priority high (repeated until Ack)",
+			"Close confirmation: close h1, close d1 (alert when bar closes beyond level)",
+			"Approaching warning: near 30p, near 20%adr (once per approach)",
+			"Directions: > (cross up), < (cross down), x (any cross)",
+			"Named levels: PDH, PDL (previous day high, low), DO (day open), WO (week open), they are resolved and rearmed every day, without direction any cross is alerted",
+			"Offset units: p (points), % (percent), atr, %adr (percent of ATR)",
+			"Symbols: EURUSD, eur/usd, cable",
+			"Spread and ratio: EURUSD-GBPUSD, AUDUSD/NZDUSD",
+			"Bulk: one command per line",
+		},
+	},
+	{
+		command: DeleteValue,
+		aliases: []string{"/rm"},
+		title:   "Delete",
+		forms: []form{
+			{
+				title: "Keyboard delete",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{}, nil
+				},
+			},
+			{
+				args:    []argSpec{idArg("ID")},
+				example: "12",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}}, nil
+				},
+			},
+			{
+				title:   "Keyboard delete",
+				args:    []argSpec{idArg("ID"), textArg("DESCRIPTION")},
+				example: "[12] EURUSD > 1.2550",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}}, nil
+				},
+			},
+			{
+				args:    []argSpec{filterArg("FILTER")},
+				example: "EUR",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{Key: a.str("FILTER"), Value: NoValue}}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), directionArg("DIRECTION"), levelArg("LEVEL")},
+				example: "EURUSD < 1.2550",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: newValue(a.str("SYMBOL"), a.valueType("DIRECTION"), a.float("LEVEL"))}, nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LEVEL")},
+				example: "EURUSD 1.2550",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: newValue(a.str("SYMBOL"), "", a.float("LEVEL"))}, nil
+				},
+			},
+		},
+	},
+	{
+		command: PercentValue,
+		title:   "Percent change",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), percentArg("PERCENT")},
+				example: "EURUSD 0.5",
+				build: func(a args) (*CommandValue, error) {
+					return newPercentValue(a.str("SYMBOL"), a.percent("PERCENT"), db.ReferenceSet), nil
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), percentArg("PERCENT"), referenceArg("REFERENCE")},
+				example: "GBPUSD -0.3% open",
+				build: func(a args) (*CommandValue, error) {
+					return newPercentValue(a.str("SYMBOL"), a.percent("PERCENT"), a.reference("REFERENCE")), nil
+				},
+			},
+		},
+		options:   valueOptions,
+		annotated: true,
+		notes: []string{
+			"Sign: + (up), - (down), no sign (any direction)",
+			"Reference: set (price when alert is set, default), open (day open)",
+		},
+	},
+	{
+		command: Trailing,
+		title:   "Trailing",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), sideArg("SIDE"), distanceArg("DISTANCE")},
+				example: "EURUSD long 50p",
+				build: func(a args) (*CommandValue, error) {
+					v := newValue(a.str("SYMBOL"), a.valueType("SIDE"), 0)
+					v.Kind = db.KindTrailing
+					v.Trailing = &db.Trailing{Distance: *a.offset("DISTANCE")}
+
+					return &CommandValue{Value: v}, nil
+				},
+			},
+		},
+		options:   valueOptions,
+		annotated: true,
+		notes: []string{
+			"Alert when price retraces by distance from the best price: long follows highs, short follows lows",
+			"Distance: 50p, 0.5%, 2atr",
+		},
+	},
+	{
+		command: Range,
+		title:   "Range",
+		forms: []form{
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LOW"), levelArg("HIGH"), rangeModeArg("MODE")},
+				example: "EURUSD 1.1000 1.1200 enter",
+				build: func(a args) (*CommandValue, error) {
+					return newRangeValue(a.str("SYMBOL"), a.float("LOW"), a.float("HIGH"), a.rangeMode("MODE"))
+				},
+			},
+			{
+				args:    []argSpec{symbolArg("SYMBOL"), levelArg("LOW"), levelArg("HIGH")},
+				example: "EURUSD 1.1000 1.1200",
+				build: func(a args) (*CommandValue, error) {
+					return newRangeValue(a.str("SYMBOL"), a.float("LOW"), a.float("HIGH"), db.RangeExit)
+				},
+			},
+		},
+		options:   valueOptions,
+		annotated: true,
+		notes: []string{
+			"Alert when price leaves the range (exit, default) or returns into it (enter)",
+		},
+	},
+	{
+		command: Edit,
+		title:   "Edit",
+		forms: []form{
+			{
+				args:    []argSpec{idArg("ID"), directionArg("DIRECTION"), priceArg("LEVEL")},
+				example: "12 > 1.2600",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID"), Type: a.valueType("DIRECTION"), Value: a.float("LEVEL")}, ValueSet: true}, nil
+				},
+			},
+			{
+				args:    []argSpec{idArg("ID"), priceArg("LEVEL")},
+				example: "12 1.2600",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID"), Value: a.float("LEVEL")}, ValueSet: true}, nil
+				},
+			},
+			{
+				args:    []argSpec{idArg("ID"), directionArg("DIRECTION")},
+				example: "12 x",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID"), Type: a.valueType("DIRECTION")}}, nil
+				},
+			},
+			{
+				args:    []argSpec{idArg("ID")},
+				example: `12 "weekly resistance" #tp`,
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{ID: a.id("ID")}}, nil
+				},
+			},
+		},
+		options:   valueOptions,
+		annotated: true,
+		notes: []string{
+			"IDs are shown in " + string(ListValues),
+			"Level of spread can be 0 or negative",
+		},
+	},
+	{
+		command: ListValues,
+		aliases: []string{"/list"},
+		title:   "List",
+		forms: []form{
+			{
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{}, nil
+				},
+			},
+			{
+				args:    []argSpec{filterArg("FILTER")},
+				example: "USD",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{Key: a.str("FILTER")}}, nil
+				},
+			},
+		},
+	},
+	{
+		command: DeltaValue,
+		title:   "Delta",
+		forms: []form{
+			{
+				args:    []argSpec{filterArg("FILTER"), pointsArg("POINTS")},
+				example: "USDJPY 500",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{Key: a.str("FILTER"), Value: float64(a.int("POINTS"))}}, nil
+				},
+			},
+			{
+				args:    []argSpec{pointsArg("POINTS")},
+				example: "500",
+				build: func(a args) (*CommandValue, error) {
+					return &CommandValue{Value: &db.Value{Value: float64(a.int("POINTS"))}}, nil
+				},
+			},
+		},
+	},
+}
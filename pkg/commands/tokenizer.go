@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// token is one word of command. Quoted text is kept as single token.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// lower return token text in lower case, quoted text is returned as is.
+func (t token) lower() string {
+	if t.quoted {
+		return t.text
+	}
+
+	return strings.ToLower(t.text)
+}
+
+func tokenize(msg string) ([]token, error) {
+	var tokens []token
+	var current strings.Builder
+	inQuotes := false
+	flush := func(quoted bool) {
+		if (current.Len() > 0) || quoted {
+			tokens = append(tokens, token{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+	}
+	for _, r := range msg {
+		switch {
+		case r == '"':
+			if inQuotes {
+				flush(true)
+			} else {
+				flush(false)
+			}
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("Unclosed quote")
+	}
+	flush(false)
+
+	return tokens, nil
+}
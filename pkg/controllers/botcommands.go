@@ -7,7 +7,9 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"fx_alert/pkg/commands"
 	"fx_alert/pkg/db"
@@ -15,7 +17,12 @@ import (
 	"fx_alert/pkg/telegram"
 )
 
+const atrPeriod = 14
+
 func processCommand(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message) (*telegram.Answer, error) {
+	if strings.Count(strings.TrimSpace(msg.Text), "\n") > 0 {
+		return processBatch(dbH, qHolder, msg)
+	}
 	cmd, err := commands.Parse(msg.Text)
 	if err != nil {
 		return nil, fmt.Errorf("Can't parse command: %w", err)
@@ -29,6 +36,74 @@ func processCommand(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message) (*
 		return processDeleteValues(dbH, msg, *cmd)
 	}
 
+	if cmd.Command == commands.PercentValue {
+		return processAddPercent(dbH, qHolder, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Trailing {
+		return processAddTrailing(dbH, qHolder, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Range {
+		return processAddRange(dbH, qHolder, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Bracket {
+		return processBracket(dbH, qHolder, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Link {
+		return processLink(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Unlink {
+		return processUnlink(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Rule {
+		return processRule(dbH, qHolder, msg, *cmd)
+	}
+
+	if (cmd.Command == commands.Touch) || (cmd.Command == commands.Retest) {
+		return processAddTouch(dbH, qHolder, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Snooze {
+		return processSnooze(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Mute {
+		return processMute(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Timezone {
+		return processTimezone(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Quiet {
+		return processQuiet(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Digest {
+		return processDigest(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Priority {
+		return processPriority(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Ack {
+		return processAck(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Momentum {
+		return processMomentum(dbH, msg, *cmd)
+	}
+
+	if cmd.Command == commands.Edit {
+		return processEditValue(dbH, qHolder, msg, *cmd)
+	}
+
 	if cmd.Command == commands.ListValues {
 		return processListValues(dbH, qHolder, msg, *cmd)
 	}
@@ -37,6 +112,10 @@ func processCommand(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message) (*
 		return processAddDeltaValues(dbH, qHolder, msg, *cmd)
 	}
 
+	if cmd.Command == commands.Grid {
+		return processGrid(dbH, qHolder, msg, *cmd)
+	}
+
 	return commands.HelpAnswer(), nil
 }
 
@@ -57,11 +136,19 @@ func ProcessBotCommands(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder,
 			continue
 		}
 		for _, upd := range upds {
+			if upd.CallbackQuery != nil {
+				processCallback(dbH, qHolder, tlg, *upd.CallbackQuery)
+				continue
+			}
 			msg := upd.Message
 			log.Printf("Got message: %v", msg)
 			answer, err := processCommand(dbH, qHolder, msg)
 			if err != nil {
 				answer = &telegram.Answer{Text: "Can't process command"}
+				var usageErr *commands.UsageError
+				if errors.As(err, &usageErr) {
+					answer.Text = usageErr.Error()
+				}
 				log.Printf("Can't process command: %q. %v", msg.Text, err)
 			}
 			if err := tlg.SendMessage(msg.Chat.ID, msg.MessageID, *answer); err != nil {
@@ -71,6 +158,33 @@ func ProcessBotCommands(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder,
 	}
 }
 
+// processCallback process command of inline button, result is shown as notification.
+func processCallback(dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram, cq telegram.CallbackQuery) {
+	log.Printf("Got callback: %v", cq)
+	if cq.Message == nil {
+		if err := tlg.AnswerCallbackQuery(cq.ID, "Message is too old"); err != nil {
+			log.Printf("Can't answer callback: %v", err)
+		}
+		return
+	}
+	msg := telegram.Message{Text: cq.Data, From: cq.From, Chat: cq.Message.Chat}
+	text := "Can't process command"
+	answer, err := processCommand(dbH, qHolder, msg)
+	if err != nil {
+		log.Printf("Can't process callback: %q. %v", cq.Data, err)
+	} else {
+		text = answer.Text
+	}
+	if err := tlg.AnswerCallbackQuery(cq.ID, text); err != nil {
+		log.Printf("Can't answer callback: %q. %v", text, err)
+	}
+	if (err == nil) && isAckCallback(cq.Data) {
+		if err := tlg.EditInlineKeyboard(msg.Chat.ID, cq.Message.MessageID, withoutAck(cq.Message.ReplyMarkup)); err != nil {
+			log.Printf("Can't remove ack button: %d. %v", cq.Message.MessageID, err)
+		}
+	}
+}
+
 func processDeleteValues(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
 	if cmd.Value == nil {
 		vals := dbH.List(msg.Chat.ID)
@@ -82,7 +196,7 @@ func processDeleteValues(dbH *db.DB, msg telegram.Message, cmd commands.CommandV
 			btns = append(
 				btns,
 				[]telegram.KeyboardButton{
-					{Text: fmt.Sprintf("%s %s", commands.DeleteValue, v.String())},
+					{Text: fmt.Sprintf("%s %s", commands.DeleteValue, valueLine(v))},
 				},
 			)
 		}
@@ -97,26 +211,134 @@ func processDeleteValues(dbH *db.DB, msg telegram.Message, cmd commands.CommandV
 		lst := dbH.List(msg.Chat.ID)
 		k := strings.ToUpper(cmd.Value.Key)
 		var deleted string
+		done := map[uint64]bool{}
 		for _, v := range lst {
+			if done[v.ID] {
+				continue
+			}
 			if k != commands.AnySymbol {
 				if !strings.Contains(strings.ToUpper(v.Key), k) {
 					continue
 				}
 			}
-			if err := dbH.DeleteKey(msg.Chat.ID, v.Key); err != nil {
+			cancelled, err := dbH.DeleteKey(msg.Chat.ID, v.Key)
+			if err != nil {
 				deleted += "\n Can't delete: " + v.String()
 				continue
 			}
 			deleted += "\n" + v.String()
+			for _, c := range cancelled {
+				done[c.ID] = true
+				deleted += "\n" + c.String()
+			}
 		}
 
 		return &telegram.Answer{Text: "Deleted: " + msg.Text + "\n" + deleted}, nil
 	}
-	if err := dbH.DeleteValue(msg.Chat.ID, *cmd.Value); err != nil {
+	if cmd.Value.ID != 0 {
+		val, err := dbH.Get(msg.Chat.ID, cmd.Value.ID)
+		if err != nil {
+			return &telegram.Answer{Text: fmt.Sprintf("Alert not found: %d", cmd.Value.ID)}, nil
+		}
+		cancelled, err := dbH.DeleteValue(msg.Chat.ID, *val)
+		if err != nil {
+			return nil, fmt.Errorf("Can't delete value: %w", err)
+		}
+
+		return &telegram.Answer{Text: "Deleted: " + valueLine(*val) + cancelledString(cancelled)}, nil
+	}
+	cancelled, err := dbH.DeleteValue(msg.Chat.ID, *cmd.Value)
+	if err != nil {
 		return nil, fmt.Errorf("Can't delete value: %w", err)
 	}
 
-	return &telegram.Answer{Text: "Deleted: " + msg.Text}, nil
+	return &telegram.Answer{Text: "Deleted: " + msg.Text + cancelledString(cancelled)}, nil
+}
+
+func processEditValue(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	val, err := dbH.Get(msg.Chat.ID, cmd.Value.ID)
+	if err != nil {
+		return &telegram.Answer{Text: fmt.Sprintf("Alert not found: %d", cmd.Value.ID)}, nil
+	}
+	edit := cmd.Value
+	changed := false
+	levelChanged := false
+	if cmd.ValueSet {
+		if _, composite := quoter.ParseComposite(val.Key); !composite && (edit.Value <= 0) {
+			return &telegram.Answer{Text: fmt.Sprintf("Level must be positive: %.5f", edit.Value)}, nil
+		}
+		val.Value = edit.Value
+		// explicit level replaces named one
+		val.Dynamic = nil
+		levelChanged = true
+	}
+	if edit.Type != "" {
+		val.Type = edit.Type
+		levelChanged = true
+	}
+	if levelChanged && (val.Kind != db.KindLevel) {
+		return &telegram.Answer{Text: "Only level alerts can change level or direction: " + valueLine(*val)}, nil
+	}
+	if levelChanged {
+		q, err := qHolder.GetCurrentQuote(val.Key)
+		if err != nil {
+			return nil, fmt.Errorf("Can't get quote to edit level: %w", err)
+		}
+		// new level without direction keeps user intention to be alerted when price reaches it
+		if (edit.Type == "") && (val.Type != db.CrossAny) {
+			vt, err := db.InferValueType(q.Close, val.Value)
+			if err != nil {
+				return &telegram.Answer{Text: fmt.Sprintf("%v: %.5f", err, q.Close)}, nil
+			}
+			val.Type = vt
+		}
+		if val.IsAlert(q.Close) {
+			return &telegram.Answer{Text: fmt.Sprintf("Level already crossed: %s. Current: %.5f", val.String(), q.Close)}, nil
+		}
+		val.SetLastPrice(q.Close)
+		val.Disarmed = false
+		changed = true
+	}
+	if cmd.NoteSet {
+		val.Note = edit.Note
+		changed = true
+	}
+	if len(edit.Tags) > 0 {
+		val.Tags = edit.Tags
+		changed = true
+	}
+	if edit.TimeInForce != "" {
+		val.TimeInForce = edit.TimeInForce
+		val.ExpiresAt = edit.ExpiresAt
+		changed = true
+	}
+	if edit.Priority != "" {
+		val.Priority = edit.Priority
+		changed = true
+	}
+	if edit.Repeat {
+		val.Repeat = true
+		if edit.Cooldown > 0 {
+			val.Cooldown = edit.Cooldown
+		}
+		if edit.MaxCount > 0 {
+			val.MaxCount = edit.MaxCount
+		}
+		if edit.Rearm > 0 {
+			val.Rearm = edit.Rearm
+		}
+		changed = true
+	}
+	if !changed {
+		return &telegram.Answer{Text: "Nothing to edit"}, nil
+	}
+	if err := dbH.Update(msg.Chat.ID, []db.Value{*val}); err != nil {
+		return nil, fmt.Errorf("Can't edit value: %w", err)
+	}
+
+	loc := userSettings(dbH, msg.Chat.ID).Location()
+
+	return &telegram.Answer{Text: "Edited: " + valueLine(*val) + " " + valueDetails(*val, loc)}, nil
 }
 
 func processAddDeltaValues(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
@@ -153,13 +375,12 @@ func processAddDeltaSettings(dbH *db.DB, msg telegram.Message, cmd commands.Comm
 func processAddDeltaLevels(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
 	symbols := quoter.GetAllowedSymbols()
 	var answer string
-	var levels []db.Value
+	added := 0
 	lk := strings.ToLower(cmd.Value.Key)
 	for _, symb := range symbols {
 		if (cmd.Value.Key != commands.AnySymbol) && !strings.Contains(strings.ToLower(symb), lk) {
 			continue
 		}
-		prec := quoter.GetPrecision(symb)
 		q, err := qHolder.GetCurrentQuote(symb)
 		if err != nil {
 			msg := fmt.Sprintf("Can`t add delta for: %q. %v\n", symb, err)
@@ -167,30 +388,17 @@ func processAddDeltaLevels(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Mess
 			log.Printf("[ERROR] Can't add delta value %s", msg)
 			continue
 		}
-		d := quoter.FromPoints(symb, int64(cmd.Value.Value))
-		levels = append(levels, db.Value{
-			Key:       symb,
-			Value:     q.Close + d,
-			Precision: prec,
-			Type:      db.BelowCurrent,
-			Delta:     uint64(cmd.Value.Value),
-		})
-		levels = append(levels, db.Value{
-			Key:       symb,
-			Value:     q.Close - d,
-			Precision: prec,
-			Type:      db.AboveCurrent,
-			Delta:     uint64(cmd.Value.Value),
-		})
-	}
-	if len(levels) > 0 {
-		if err := dbH.Add(msg.Chat.ID, levels); err != nil {
+		if err := dbH.AddGroup(msg.Chat.ID, deltaValues(symb, q.Close, uint64(cmd.Value.Value))); err != nil {
 			msg := fmt.Sprintf("Can't save db: %v\n", err)
 			log.Printf("[ERROR] %s", msg)
 			answer += msg
+			continue
 		}
+		added++
+	}
+	if added > 0 {
 		answer = "Added levels: " + msg.Text + "\n" + answer
-	} else {
+	} else if answer == "" {
 		answer = "Symbol is not allowed: " + msg.Text
 	}
 
@@ -204,8 +412,12 @@ func processListValues(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message,
 	}
 	answer := ""
 	sort.Slice(vals, func(i, j int) bool {
-		return vals[i].Key <= vals[j].Key
+		if vals[i].Key == vals[j].Key {
+			return vals[i].ID < vals[j].ID
+		}
+		return vals[i].Key < vals[j].Key
 	})
+	loc := userSettings(dbH, msg.Chat.ID).Location()
 	var filter string
 	if cmd.Value != nil {
 		filter = strings.ToUpper(cmd.Value.Key)
@@ -215,14 +427,17 @@ func processListValues(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message,
 			continue
 		}
 		curr := 0.0
+		distance := ""
 		if q, err := qHolder.GetCurrentQuote(v.Key); err == nil {
 			curr = q.Close
+			distance = valueDistance(v, q)
 		}
 		answer += fmt.Sprintf(
-			"%s (%.5f) (%d) \n",
-			v.String(),
+			"%s (%.5f) (%s) %s\n",
+			valueLine(v),
 			curr,
-			quoter.ToPoints(v.Key, math.Abs(curr-v.Value)),
+			distance,
+			valueDetails(v, loc),
 		)
 	}
 	if answer == "" {
@@ -233,26 +448,336 @@ func processListValues(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message,
 }
 
 func processAddValue(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
-	if !quoter.IsValidSymbol(cmd.Value.Key) {
-		return nil, errors.New("Invalid symbol")
+	val, rejected, err := prepareValue(qHolder, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return rejected, nil
 	}
-	if err := dbH.Add(msg.Chat.ID, []db.Value{*cmd.Value}); err != nil {
+	added := []db.Value{*val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
 		return nil, fmt.Errorf("Can't add value: %w", err)
 	}
+	val.ID = added[0].ID
 	diffS := ""
-	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	q, err := qHolder.GetCurrentQuote(val.Key)
 	if err != nil {
-		log.Printf("Can't get diff for: %q. %v", cmd.Value.Key, err)
+		log.Printf("Can't get diff for: %q. %v", val.Key, err)
 	}
 	if err == nil {
-		diff := math.Abs(q.Close - cmd.Value.Value)
+		diff := math.Abs(q.Close - val.Value)
 		diffS = fmt.Sprintf(
 			"Diff: %.5f (%d) \nCurrent: %.5f",
 			diff,
-			quoter.ToPoints(cmd.Value.Key, diff),
+			quoter.ToPoints(val.Key, diff),
 			q.Close,
 		)
 	}
 
-	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \n%s", msg.Text, diffS)}, nil
+	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \n%s", valueLine(*val), diffS)}, nil
+}
+
+// prepareValue resolve level and direction of value to add.
+// Answer is returned if value is rejected.
+func prepareValue(qHolder *quoter.Holder, cmd commands.CommandValue) (*db.Value, *telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return nil, invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	val := *cmd.Value
+	q, err := qHolder.GetCurrentQuote(val.Key)
+	if val.Dynamic != nil {
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't resolve named level: %w", err)
+		}
+		d := *val.Dynamic
+		val.Dynamic = &d
+		now := time.Now()
+		lvl, err := dynamicLevel(qHolder, val.Key, d.Level, now)
+		if err != nil {
+			return nil, &telegram.Answer{Text: fmt.Sprintf("Can't resolve %s: %v", strings.ToUpper(string(d.Level)), err)}, nil
+		}
+		val.SetSession(sessionStart(now), lvl, q.Close)
+	}
+	if cmd.Relative != nil {
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't resolve relative level: %w", err)
+		}
+		lvl, err := resolveOffset(qHolder, val.Key, q.Close, *cmd.Relative)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't resolve relative level: %w", err)
+		}
+		val.Value = lvl
+	}
+	if val.Type == "" {
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't infer direction: %w", err)
+		}
+		vt, err := db.InferValueType(q.Close, val.Value)
+		if err != nil {
+			return nil, &telegram.Answer{Text: fmt.Sprintf("%v: %.5f", err, q.Close)}, nil
+		}
+		val.Type = vt
+	} else if (err == nil) && (val.Dynamic == nil) && val.IsAlert(q.Close) {
+		return nil, crossedLevelAnswer(val, q.Close), nil
+	}
+	price := 0.0
+	if err == nil {
+		price = q.Close
+	}
+	setCreated(&val, price, db.OriginManual)
+	if err != nil {
+		// value is checked from the first quote
+		val.Checked = false
+	}
+	initProximity(qHolder, &val, price)
+	if answer := initConfirm(&val); answer != nil {
+		return nil, answer, nil
+	}
+
+	return &val, nil, nil
+}
+
+// processBatch add values from all lines of message or nothing.
+func processBatch(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message) (*telegram.Answer, error) {
+	cmds, err := commands.ParseBatch(msg.Text)
+	if err != nil {
+		return &telegram.Answer{Text: fmt.Sprintf("Nothing added. %v", err)}, nil
+	}
+	var levels []db.Value
+	var problems []string
+	for i, cmd := range cmds {
+		if cmd.Command != commands.AddValue {
+			problems = append(problems, fmt.Sprintf("Command %d: only %s is supported", i+1, commands.AddValue))
+			continue
+		}
+		val, rejected, err := prepareValue(qHolder, cmd)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Command %d: %v", i+1, err))
+			continue
+		}
+		if rejected != nil {
+			problems = append(problems, fmt.Sprintf("Command %d: %s", i+1, rejected.Text))
+			continue
+		}
+		val.Origin = db.OriginImport
+		levels = append(levels, *val)
+	}
+	if len(problems) > 0 {
+		return &telegram.Answer{Text: "Nothing added:\n" + strings.Join(problems, "\n")}, nil
+	}
+	if err := dbH.Add(msg.Chat.ID, levels); err != nil {
+		return nil, fmt.Errorf("Can't add values: %w", err)
+	}
+	// duplicates are not added, they have no ID
+	var added []db.Value
+	var existing []db.Value
+	for _, v := range levels {
+		if v.ID == 0 {
+			existing = append(existing, v)
+			continue
+		}
+		added = append(added, v)
+	}
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "Added:\n"+valuesList(added))
+	}
+	if len(existing) > 0 {
+		parts = append(parts, "Already exists:\n"+valuesList(existing))
+	}
+
+	return &telegram.Answer{Text: strings.Join(parts, "\n")}, nil
+}
+
+func processGrid(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for grid: %w", err)
+	}
+	if answer := initConfirm(cmd.Value); answer != nil {
+		return answer, nil
+	}
+	var levels []db.Value
+	for _, lvl := range cmd.Grid.Levels(cmd.Value.Key) {
+		vt, err := db.InferValueType(q.Close, lvl)
+		if err != nil {
+			continue
+		}
+		val := *cmd.Value
+		val.Value = lvl
+		val.Type = vt
+		setCreated(&val, q.Close, db.OriginGrid)
+		initProximity(qHolder, &val, q.Close)
+		levels = append(levels, val)
+	}
+	if len(levels) == 0 {
+		return &telegram.Answer{Text: "No grid levels"}, nil
+	}
+	if err := dbH.Add(msg.Chat.ID, levels); err != nil {
+		return nil, fmt.Errorf("Can't add grid: %w", err)
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added grid (current: %.5f):\n%s", q.Close, valuesList(levels))}, nil
+}
+
+// setCreated set creation metadata and the last checked price.
+func setCreated(val *db.Value, price float64, origin db.Origin) {
+	val.SetLastPrice(price)
+	val.CreatedPrice = price
+	val.CreatedAt = time.Now()
+	if val.Origin == "" {
+		val.Origin = origin
+	}
+}
+
+// valueDistance return distance from current price to trigger of value.
+func valueDistance(v db.Value, q *quoter.Quote) string {
+	if v.Kind == db.KindPercent {
+		return fmt.Sprintf("%.2f%%", db.ChangePercent(percentReference(v, q), q.Close))
+	}
+	if (v.Kind == db.KindRange) && (v.Range != nil) {
+		return strconv.FormatInt(quoter.ToPoints(v.Key, v.Range.Distance(q.Close)), 10)
+	}
+	if (v.Kind == db.KindRule) && (v.Rule != nil) {
+		return fmt.Sprintf("last: %v", v.Rule.Active)
+	}
+
+	return strconv.FormatInt(quoter.ToPoints(v.Key, math.Abs(q.Close-v.Value)), 10)
+}
+
+// valueDetails return optional properties of value for lists.
+func valueDetails(v db.Value, loc *time.Location) string {
+	var details []string
+	if exp := expirationString(v, loc); exp != "" {
+		details = append(details, exp)
+	}
+	if c := confirmString(v); c != "" {
+		details = append(details, c)
+	}
+	if t := touchString(v, loc); t != "" {
+		details = append(details, t)
+	}
+	if d := dynamicString(v); d != "" {
+		details = append(details, d)
+	}
+	if p := proximityString(v); p != "" {
+		details = append(details, p)
+	}
+	if v.Group != 0 {
+		details = append(details, fmt.Sprintf("group %d", v.Group))
+	}
+	if v.Priority != "" {
+		details = append(details, "priority "+string(v.Priority))
+	}
+	if v.Note != "" {
+		details = append(details, strconv.Quote(v.Note))
+	}
+	if len(v.Tags) > 0 {
+		details = append(details, "#"+strings.Join(v.Tags, " #"))
+	}
+	if v.Repeat {
+		r := fmt.Sprintf("repeat %d", v.Count)
+		if v.MaxCount > 0 {
+			r += fmt.Sprintf("/%d", v.MaxCount)
+		}
+		if v.Cooldown > 0 {
+			r += " cooldown " + v.Cooldown.String()
+		}
+		if v.Rearm > 0 {
+			r += fmt.Sprintf(" rearm %d", v.Rearm)
+		}
+		if v.Disarmed {
+			r += " (disarmed)"
+		}
+		details = append(details, r)
+	}
+
+	return strings.Join(details, "; ")
+}
+
+func invalidSymbolAnswer(symbol string) *telegram.Answer {
+	text := fmt.Sprintf("Invalid symbol: %s", symbol)
+	if suggestions := quoter.SuggestSymbols(symbol, 3); len(suggestions) > 0 {
+		text += fmt.Sprintf(". Did you mean: %s?", strings.Join(suggestions, ", "))
+	}
+
+	return &telegram.Answer{Text: text}
+}
+
+func valuesList(vals []db.Value) string {
+	lines := make([]string, 0, len(vals))
+	for _, v := range vals {
+		lines = append(lines, valueLine(v))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// valueLine is value with ID.
+func valueLine(v db.Value) string {
+	if v.ID == 0 {
+		return v.String()
+	}
+
+	return fmt.Sprintf("[%d] %s", v.ID, v.String())
+}
+
+// resolveOffset return level shifted from price by offset.
+func resolveOffset(qHolder *quoter.Holder, symb string, price float64, off db.Offset) (float64, error) {
+	d, err := offsetDistance(qHolder, symb, price, off)
+	if err != nil {
+		return 0, err
+	}
+	lvl := price + d
+	// spread of symbols can be negative
+	if _, composite := quoter.ParseComposite(symb); (lvl <= 0) && !composite {
+		return 0, fmt.Errorf("Level must be > 0: %.5f", lvl)
+	}
+	p := math.Pow10(int(quoter.GetPrecision(symb)))
+
+	return math.Round(lvl*p) / p, nil
+}
+
+// offsetDistance return signed price distance of offset from price.
+func offsetDistance(qHolder *quoter.Holder, symb string, price float64, off db.Offset) (float64, error) {
+	switch off.Unit {
+	case db.OffsetPoints:
+		return quoter.FromPoints(symb, int64(off.Amount)), nil
+	case db.OffsetPercent:
+		return price * off.Amount / 100, nil
+	case db.OffsetATR, db.OffsetADR:
+		atr, err := qHolder.GetATR(symb, time.Now(), atrPeriod)
+		if err != nil {
+			return 0, fmt.Errorf("Can't get ATR: %w", err)
+		}
+		if off.Unit == db.OffsetADR {
+			return atr * off.Amount / 100, nil
+		}
+
+		return atr * off.Amount, nil
+	}
+
+	return 0, fmt.Errorf("Unsupported offset unit: %q", off.Unit)
+}
+
+// crossedLevelAnswer reject level which would be triggered immediately and offer opposite direction.
+func crossedLevelAnswer(val db.Value, current float64) *telegram.Answer {
+	text := fmt.Sprintf("Level already crossed: %s. Current: %.5f", val.String(), current)
+	vt, err := db.InferValueType(current, val.Value)
+	if err != nil {
+		return &telegram.Answer{Text: text}
+	}
+	val.Type = vt
+	rk := &telegram.ReplyKeyboardMarkup{
+		Keyboard: [][]telegram.KeyboardButton{
+			{{Text: fmt.Sprintf("%s %s", commands.AddValue, val.String())}},
+		},
+		OneTimeKeyboard: true,
+	}
+
+	return &telegram.Answer{Text: text + "\nConfirm: ", ReplyKeyboard: rk}
 }
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+// lastClosedBar return start of the last closed bar of timeframe at time t.
+func lastClosedBar(symbol string, tf db.Timeframe, t time.Time) time.Time {
+	if tf == db.TimeframeDay {
+		d := quoter.PreviousDay(symbol, t)
+
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	return t.UTC().Truncate(time.Hour).Add(-time.Hour)
+}
+
+// barQuote return bar of timeframe which started at start, bar of the same hour or day of other date isn't returned.
+func barQuote(qHolder *quoter.Holder, symbol string, tf db.Timeframe, start time.Time) (*quoter.Quote, error) {
+	symbol = strings.ToUpper(symbol)
+	if tf == db.TimeframeDay {
+		return qHolder.GetQuoteByDay(symbol, start)
+	}
+
+	return qHolder.GetQuoteByHour(symbol, start)
+}
+
+// initConfirm start close confirmation from the next closed bar.
+// Answer is returned if value can't be confirmed by close.
+func initConfirm(val *db.Value) *telegram.Answer {
+	if val.Confirm == nil {
+		return nil
+	}
+	if _, composite := quoter.ParseComposite(val.Key); composite {
+		return &telegram.Answer{Text: "Close confirmation is not supported for spread and ratio: " + val.Key}
+	}
+	c := *val.Confirm
+	c.LastBar = lastClosedBar(val.Key, c.Timeframe, time.Now())
+	c.LastClose = 0
+	val.Confirm = &c
+
+	return nil
+}
+
+// isCloseConfirmed check close of the last closed bar, bar without quote is checked later.
+// Error is returned while bar has no quote, it names the bar, so it is logged once per bar.
+func isCloseConfirmed(qHolder *quoter.Holder, val *db.Value, t time.Time) (bool, error) {
+	start := lastClosedBar(val.Key, val.Confirm.Timeframe, t)
+	if !start.After(val.Confirm.LastBar) {
+		return false, nil
+	}
+	q, err := barQuote(qHolder, val.Key, val.Confirm.Timeframe, start)
+	if err != nil {
+		return false, fmt.Errorf("Can't get %s bar %s to confirm level: %w", val.Confirm.Timeframe, start.Format("2006-01-02 15:04"), err)
+	}
+
+	return val.IsCloseBeyond(start, q.Close), nil
+}
+
+// confirmString describe close confirmation for lists and alerts.
+func confirmString(v db.Value) string {
+	if v.Confirm == nil {
+		return ""
+	}
+	if v.Confirm.LastClose == 0 {
+		return fmt.Sprintf("close %s", v.Confirm.Timeframe)
+	}
+
+	return fmt.Sprintf("close %s (last %.5f)", v.Confirm.Timeframe, v.Confirm.LastClose)
+}
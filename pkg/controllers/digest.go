@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/telegram"
+)
+
+// maxMessageLength is limit of telegram message text.
+const maxMessageLength = 4096
+
+// digestMessage is merged message and number of notifications in it.
+type digestMessage struct {
+	answer telegram.Answer
+	count  int
+}
+
+// ProcessDigests send notifications stored during digest window when it is over.
+func ProcessDigests(ctx context.Context, dbH *db.DB, tlg *telegram.Telegram) {
+	log.Printf("Digest controller started")
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			for _, ID := range dbH.Users() {
+				sendDigest(dbH, tlg, ID, t)
+			}
+		}
+	}
+}
+
+// sendDigest send digest of user if its window is over, notifications are removed only after they are sent.
+func sendDigest(dbH *db.DB, tlg *telegram.Telegram, ID int64, t time.Time) {
+	ns := dbH.Digest(ID)
+	if len(ns) == 0 {
+		return
+	}
+	if t.Sub(ns[0].Time) < userSettings(dbH, ID).DigestWindow {
+		return
+	}
+	answers := make([]telegram.Answer, 0, len(ns))
+	for _, n := range ns {
+		answers = append(answers, notificationAnswer(n))
+	}
+	for _, m := range mergeAnswers(answers) {
+		if err := deliver(dbH, tlg, ID, m.answer); err != nil {
+			log.Printf("[ERROR] Can't send digest to %d. %v. %s", ID, err, m.answer.Text)
+			return
+		}
+		log.Printf("[INFO] Digest sent %d. %s", ID, m.answer.Text)
+		if err := dbH.RemoveDigest(ID, m.count); err != nil {
+			log.Printf("[ERROR] Can't remove sent digest: %d. %v", ID, err)
+			return
+		}
+	}
+}
+
+// answerNotification convert answer to stored notification.
+func answerNotification(answer telegram.Answer, t time.Time) db.Notification {
+	n := db.Notification{Text: answer.Text, Time: t, Silent: answer.DisableNotification}
+	if answer.InlineKeyboard == nil {
+		return n
+	}
+	for _, row := range answer.InlineKeyboard.InlineKeyboard {
+		btns := make([]db.Button, 0, len(row))
+		for _, btn := range row {
+			btns = append(btns, db.Button{Text: btn.Text, Data: btn.CallbackData})
+		}
+		n.Buttons = append(n.Buttons, btns)
+	}
+
+	return n
+}
+
+// notificationAnswer convert stored notification to answer.
+func notificationAnswer(n db.Notification) telegram.Answer {
+	answer := telegram.Answer{Text: n.Text, DisableNotification: n.Silent}
+	if len(n.Buttons) == 0 {
+		return answer
+	}
+	rows := make([][]telegram.InlineKeyboardButton, 0, len(n.Buttons))
+	for _, row := range n.Buttons {
+		btns := make([]telegram.InlineKeyboardButton, 0, len(row))
+		for _, btn := range row {
+			btns = append(btns, telegram.InlineKeyboardButton{Text: btn.Text, CallbackData: btn.Data})
+		}
+		rows = append(rows, btns)
+	}
+	answer.InlineKeyboard = &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	return answer
+}
+
+// mergeAnswers join answers to digest messages, single answer is kept as is.
+// Notifications are numbered and their buttons are prefixed by number, so they are kept in digest.
+func mergeAnswers(answers []telegram.Answer) []digestMessage {
+	if len(answers) == 0 {
+		return nil
+	}
+	if len(answers) == 1 {
+		return []digestMessage{{answer: answers[0], count: 1}}
+	}
+	texts := make([]string, 0, len(answers))
+	for i, a := range answers {
+		texts = append(texts, fmt.Sprintf("%d. %s", i+1, a.Text))
+	}
+
+	return joinAnswers(fmt.Sprintf("Digest: %d notifications", len(answers)), answers, texts, "\n\n")
+}
+
+// joinAnswers join numbered texts of answers after header to messages.
+// Buttons of answers are prefixed by number, message is silent if all its answers are silent.
+func joinAnswers(header string, answers []telegram.Answer, texts []string, sep string) []digestMessage {
+	var merged []digestMessage
+	for _, c := range chunkText(header, texts, sep) {
+		silent := true
+		var rows [][]telegram.InlineKeyboardButton
+		for _, i := range c.parts {
+			a := answers[i]
+			silent = silent && a.DisableNotification
+			if a.InlineKeyboard == nil {
+				continue
+			}
+			for _, row := range a.InlineKeyboard.InlineKeyboard {
+				btns := make([]telegram.InlineKeyboardButton, 0, len(row))
+				for _, btn := range row {
+					btns = append(btns, telegram.InlineKeyboardButton{Text: fmt.Sprintf("%d: %s", i+1, btn.Text), CallbackData: btn.CallbackData})
+				}
+				rows = append(rows, btns)
+			}
+		}
+		answer := telegram.Answer{Text: c.text, DisableNotification: silent}
+		if len(rows) > 0 {
+			answer.InlineKeyboard = &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+		}
+		merged = append(merged, digestMessage{answer: answer, count: len(c.parts)})
+	}
+
+	return merged
+}
+
+// textChunk is message text and indexes of parts in it.
+type textChunk struct {
+	text  string
+	parts []int
+}
+
+// chunkText join parts after header to messages which fit telegram limit, too long part is truncated.
+func chunkText(header string, parts []string, sep string) []textChunk {
+	var chunks []textChunk
+	current := textChunk{text: header}
+	for i, p := range parts {
+		p = truncateText(p, maxMessageLength-len(header)-len(sep))
+		if (len(current.parts) > 0) && (len(current.text)+len(sep)+len(p) > maxMessageLength) {
+			chunks = append(chunks, current)
+			current = textChunk{text: header}
+		}
+		current.text += sep + p
+		current.parts = append(current.parts, i)
+	}
+
+	return append(chunks, current)
+}
+
+// truncateText cut text to n bytes without splitting UTF-8 character.
+func truncateText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for (n > 0) && !utf8.RuneStart(text[n]) {
+		n--
+	}
+
+	return text[:n]
+}
+
+func processDigest(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	switch cmd.Action {
+	case commands.ActionList:
+		if us.DigestWindow == 0 {
+			return &telegram.Answer{Text: "Digest is disabled"}, nil
+		}
+
+		return &telegram.Answer{Text: "Digest window: " + us.DigestWindow.String()}, nil
+	case commands.ActionDelete:
+		us.DigestWindow = 0
+	default:
+		us.DigestWindow = cmd.Window
+	}
+	if err := dbH.SetSettings(msg.Chat.ID, us); err != nil {
+		return nil, fmt.Errorf("Can't set digest: %w", err)
+	}
+	if us.DigestWindow == 0 {
+		return &telegram.Answer{Text: "Digest is disabled"}, nil
+	}
+
+	return &telegram.Answer{Text: "Digest window: " + us.DigestWindow.String()}, nil
+}
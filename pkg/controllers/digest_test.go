@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/telegram"
+)
+
+func TestMergeAnswers(t *testing.T) {
+	snooze := &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "Snooze 1h", CallbackData: "/snooze 3 1h"}}},
+	}
+	single := []telegram.Answer{{Text: "EURUSD > 1.2", InlineKeyboard: snooze}}
+	if got := mergeAnswers(single); (len(got) != 1) || (got[0].count != 1) || !reflect.DeepEqual(got[0].answer, single[0]) {
+		t.Fatalf("Expect single answer as is, got %v", got)
+	}
+	answers := []telegram.Answer{
+		{Text: "EURUSD > 1.2", InlineKeyboard: snooze},
+		{Text: "Diff: USDJPY 5m - 60", DisableNotification: true},
+	}
+	got := mergeAnswers(answers)
+	if (len(got) != 1) || (got[0].count != 2) {
+		t.Fatalf("Expect one digest of 2 notifications, got %v", got)
+	}
+	expect := "Digest: 2 notifications\n\n1. EURUSD > 1.2\n\n2. Diff: USDJPY 5m - 60"
+	if got[0].answer.Text != expect {
+		t.Fatalf("Expect: %q, got %q", expect, got[0].answer.Text)
+	}
+	if got[0].answer.DisableNotification {
+		t.Fatalf("Expect sound if any notification has it")
+	}
+	kb := got[0].answer.InlineKeyboard
+	if (kb == nil) || (len(kb.InlineKeyboard) != 1) || (kb.InlineKeyboard[0][0] != telegram.InlineKeyboardButton{Text: "1: Snooze 1h", CallbackData: "/snooze 3 1h"}) {
+		t.Fatalf("Unexpected keyboard: %v", kb)
+	}
+	if got := mergeAnswers(nil); len(got) != 0 {
+		t.Fatalf("Expect nothing, got %v", got)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	type tableData struct {
+		parts  []string
+		expect []int
+	}
+
+	long := strings.Repeat("a", 3000)
+	data := []tableData{
+		{parts: []string{"a", "b"}, expect: []int{2}},
+		{parts: []string{long, long, "b"}, expect: []int{1, 2}},
+		{parts: []string{strings.Repeat("b", 5000), "c"}, expect: []int{1, 1}},
+	}
+	for i, d := range data {
+		chunks := chunkText("Header:", d.parts, "\n")
+		if len(chunks) != len(d.expect) {
+			t.Fatalf("Test %d Expect %d messages, got %d", i, len(d.expect), len(chunks))
+		}
+		for j, c := range chunks {
+			if len(c.parts) != d.expect[j] {
+				t.Fatalf("Test %d Expect %d parts in message %d, got %d", i, d.expect[j], j, len(c.parts))
+			}
+			if (len(c.text) > maxMessageLength) || !strings.HasPrefix(c.text, "Header:\n") {
+				t.Fatalf("Test %d Unexpected message %d: %d bytes", i, j, len(c.text))
+			}
+		}
+	}
+	// note of cyrillic letters of 2 bytes must not be cut in the middle of letter
+	note := strings.Repeat("я", 3000)
+	msgs := chunkText("Header", []string{note}, "\n")
+	if (len(msgs) != 1) || (len(msgs[0].text) > maxMessageLength) || !utf8.ValidString(msgs[0].text) {
+		t.Fatalf("Unexpected split: %d messages, %d bytes, valid %v", len(msgs), len(msgs[0].text), utf8.ValidString(msgs[0].text))
+	}
+}
+
+func TestDeferredMessages(t *testing.T) {
+	start := time.Date(2021, 6, 1, 22, 30, 0, 0, time.UTC)
+	ns := []db.Notification{
+		{Text: "EURUSD > 1.2", Time: start, Buttons: [][]db.Button{{{Text: "Snooze 1h", Data: "/snooze 3 1h"}}}},
+		{Text: "Diff: USDJPY 5m - 60", Time: start.Add(time.Hour), Silent: true},
+	}
+	msgs := deferredMessages(ns, time.UTC)
+	if (len(msgs) != 1) || (msgs[0].count != 2) {
+		t.Fatalf("Expect one message of 2 notifications, got %v", msgs)
+	}
+	expect := "During quiet hours:\n1. 22:30 EURUSD > 1.2\n2. 23:30 Diff: USDJPY 5m - 60"
+	if msgs[0].answer.Text != expect {
+		t.Fatalf("Expect: %q, got %q", expect, msgs[0].answer.Text)
+	}
+	kb := msgs[0].answer.InlineKeyboard
+	if (kb == nil) || (kb.InlineKeyboard[0][0] != telegram.InlineKeyboardButton{Text: "1: Snooze 1h", CallbackData: "/snooze 3 1h"}) {
+		t.Fatalf("Expect buttons of deferred alert are kept, got %v", kb)
+	}
+}
+
+func TestNotificationAnswer(t *testing.T) {
+	answer := telegram.Answer{
+		Text:                "EURUSD > 1.2",
+		DisableNotification: true,
+		InlineKeyboard: &telegram.InlineKeyboardMarkup{
+			InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "Snooze 1h", CallbackData: "/snooze 3 1h"}}},
+		},
+	}
+	n := answerNotification(answer, time.Now())
+	if got := notificationAnswer(n); !reflect.DeepEqual(got, answer) {
+		t.Fatalf("Expect: %v, got %v", answer, got)
+	}
+	plain := telegram.Answer{Text: "EURUSD > 1.2"}
+	if got := notificationAnswer(answerNotification(plain, time.Now())); !reflect.DeepEqual(got, plain) {
+		t.Fatalf("Expect: %v, got %v", plain, got)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+)
+
+// sessionStart return start of UTC day, named levels are resolved once per day.
+func sessionStart(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dynamicLevel resolve named level from quotes of holder at time t.
+func dynamicLevel(qHolder *quoter.Holder, symbol string, level db.DynamicLevel, t time.Time) (float64, error) {
+	symbol = strings.ToUpper(symbol)
+	switch level {
+	case db.DynamicPDH, db.DynamicPDL:
+		q, err := qHolder.GetQuoteByDay(symbol, quoter.PreviousDay(symbol, t))
+		if err != nil {
+			return 0, fmt.Errorf("Can't get previous day of %s: %w", symbol, err)
+		}
+		if level == db.DynamicPDH {
+			return q.High, nil
+		}
+
+		return q.Low, nil
+	case db.DynamicDO:
+		q, err := qHolder.GetCurrentQuote(symbol)
+		if err != nil {
+			return 0, fmt.Errorf("Can't get current day of %s: %w", symbol, err)
+		}
+
+		return q.Open, nil
+	case db.DynamicWO:
+		open, err := qHolder.GetWeekOpen(symbol, t)
+		if err != nil {
+			return 0, fmt.Errorf("Can't get week open of %s: %w", symbol, err)
+		}
+
+		return open, nil
+	}
+
+	return 0, fmt.Errorf("Unsupported level: %q", level)
+}
+
+// rearmDynamic resolve named level of value for the current session.
+// True is returned if value is rearmed with new level.
+func rearmDynamic(qHolder *quoter.Holder, val *db.Value, price float64, t time.Time) (bool, error) {
+	if (val.Dynamic == nil) || val.Dynamic.Session.Equal(sessionStart(t)) {
+		return false, nil
+	}
+	lvl, err := dynamicLevel(qHolder, val.Key, val.Dynamic.Level, t)
+	if err != nil {
+		return false, err
+	}
+
+	return val.SetSession(sessionStart(t), lvl, price), nil
+}
+
+// dynamicString describe state of named level for lists.
+func dynamicString(v db.Value) string {
+	if v.Dynamic == nil {
+		return ""
+	}
+	s := "session " + v.Dynamic.Session.Format("2006-01-02")
+	if v.Dynamic.Fired {
+		s += ", fired"
+	}
+
+	return s
+}
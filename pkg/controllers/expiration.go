@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/telegram"
+)
+
+// ProcessExpiration delete expired values and notify users about them.
+func ProcessExpiration(ctx context.Context, dbH *db.DB, tlg *telegram.Telegram) {
+	log.Printf("Expiration controller started")
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			expired, err := dbH.DeleteExpired(t)
+			if err != nil {
+				log.Printf("[ERROR] Can't delete expired values: %v", err)
+			}
+			for ID, vals := range expired {
+				answer := telegram.Answer{Text: "Expired:\n" + valuesList(vals)}
+				if err := notify(dbH, tlg, ID, answer, db.PriorityNormal); err != nil {
+					log.Printf("[ERROR] Can't send expired values to %d. %v. %s", ID, err, answer.Text)
+					continue
+				}
+				log.Printf("[INFO] Expired values sent %d. %s", ID, answer.Text)
+			}
+		}
+	}
+}
+
+// expirationString return empty string for good-till-cancelled values.
+func expirationString(v db.Value, loc *time.Location) string {
+	if v.ExpiresAt.IsZero() {
+		return ""
+	}
+
+	return string(v.TimeInForce) + " " + formatTime(v.ExpiresAt, loc)
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"sync"
+
+	"fx_alert/pkg/db"
+)
+
+type failureKey struct {
+	ID    int64
+	ValID uint64
+}
+
+// failureLog is the last logged failure by value, so errors of check are logged once per state.
+type failureLog struct {
+	m      sync.Mutex
+	states map[failureKey]string
+}
+
+func newFailureLog() *failureLog {
+	return &failureLog{states: map[failureKey]string{}}
+}
+
+// isNew return true if failure state of value isn't logged yet.
+func (f *failureLog) isNew(ID int64, valID uint64, state string) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+	key := failureKey{ID: ID, ValID: valID}
+	if s, exists := f.states[key]; exists && (s == state) {
+		return false
+	}
+	f.states[key] = state
+
+	return true
+}
+
+// reset forget failure of value, the next one is logged again.
+func (f *failureLog) reset(ID int64, valID uint64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	delete(f.states, failureKey{ID: ID, ValID: valID})
+}
+
+// prune forget failures of deleted values of user.
+func (f *failureLog) prune(ID int64, values []db.Value) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	exists := make(map[uint64]bool, len(values))
+	for _, v := range values {
+		exists[v.ID] = true
+	}
+	for key := range f.states {
+		if (key.ID == ID) && !exists[key.ValID] {
+			delete(f.states, key)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"testing"
+
+	"fx_alert/pkg/db"
+)
+
+func TestFailureLog(t *testing.T) {
+	f := newFailureLog()
+	type tableData struct {
+		ID     int64
+		valID  uint64
+		state  string
+		expect bool
+	}
+
+	data := []tableData{
+		{ID: 1, valID: 1, state: "dynamic 2021-06-01", expect: true},
+		{ID: 1, valID: 1, state: "dynamic 2021-06-01", expect: false},
+		{ID: 1, valID: 2, state: "dynamic 2021-06-01", expect: true},
+		{ID: 2, valID: 1, state: "dynamic 2021-06-01", expect: true},
+		{ID: 1, valID: 1, state: "dynamic 2021-06-02", expect: true},
+	}
+	for i, d := range data {
+		if got := f.isNew(d.ID, d.valID, d.state); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+	f.reset(1, 1)
+	if !f.isNew(1, 1, "dynamic 2021-06-02") {
+		t.Fatalf("Expect failure is logged again after reset")
+	}
+	f.prune(1, []db.Value{{ID: 2}})
+	if _, exists := f.states[failureKey{ID: 1, ValID: 1}]; exists {
+		t.Fatalf("Expect failure of deleted value is forgotten")
+	}
+	if len(f.states) != 2 {
+		t.Fatalf("Expect failures of other values are kept, got %v", f.states)
+	}
+}
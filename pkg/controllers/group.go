@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+func processBracket(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for bracket: %w", err)
+	}
+	low, high := cmd.Bracket.Low, cmd.Bracket.High
+	if d := cmd.Bracket.Distance; d != nil {
+		high, err = resolveOffset(qHolder, cmd.Value.Key, q.Close, *d)
+		if err != nil {
+			return &telegram.Answer{Text: fmt.Sprintf("Can't resolve distance: %v", err)}, nil
+		}
+		low, err = resolveOffset(qHolder, cmd.Value.Key, q.Close, db.Offset{Amount: -d.Amount, Unit: d.Unit})
+		if err != nil {
+			return &telegram.Answer{Text: fmt.Sprintf("Can't resolve distance: %v", err)}, nil
+		}
+	}
+	if (low >= q.Close) || (high <= q.Close) {
+		return &telegram.Answer{Text: fmt.Sprintf("Current price must be between levels: %.5f", q.Close)}, nil
+	}
+	if answer := initConfirm(cmd.Value); answer != nil {
+		return answer, nil
+	}
+	upper := *cmd.Value
+	upper.Value = high
+	upper.Type = db.BelowCurrent
+	setCreated(&upper, q.Close, db.OriginManual)
+	initProximity(qHolder, &upper, q.Close)
+	lower := *cmd.Value
+	lower.Value = low
+	lower.Type = db.AboveCurrent
+	setCreated(&lower, q.Close, db.OriginManual)
+	initProximity(qHolder, &lower, q.Close)
+	levels := []db.Value{upper, lower}
+	if err := dbH.AddGroup(msg.Chat.ID, levels); err != nil {
+		return nil, fmt.Errorf("Can't add bracket: %w", err)
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added bracket (current: %.5f):\n%s", q.Close, valuesList(levels))}, nil
+}
+
+func processLink(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	group, err := dbH.Link(msg.Chat.ID, cmd.IDs)
+	if errors.Is(err, db.ErrValueNotFound) || errors.Is(err, db.ErrUserNotFound) {
+		return &telegram.Answer{Text: "Alert not found: " + idsString(cmd.IDs)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Can't link values: %w", err)
+	}
+	var linked []db.Value
+	for _, v := range dbH.List(msg.Chat.ID) {
+		if v.Group == group {
+			linked = append(linked, v)
+		}
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Linked group %d:\n%s", group, valuesList(linked))}, nil
+}
+
+func processUnlink(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	ID := cmd.IDs[0]
+	val, err := dbH.Get(msg.Chat.ID, ID)
+	if err != nil {
+		return &telegram.Answer{Text: fmt.Sprintf("Alert not found: %d", ID)}, nil
+	}
+	if val.Group == 0 {
+		return &telegram.Answer{Text: "Alert is not linked: " + valueLine(*val)}, nil
+	}
+	if err := dbH.Unlink(msg.Chat.ID, ID); err != nil {
+		return nil, fmt.Errorf("Can't unlink value: %w", err)
+	}
+
+	return &telegram.Answer{Text: "Unlinked: " + valueLine(*val)}, nil
+}
+
+// cancelledString return list of cancelled linked values for messages.
+func cancelledString(vals []db.Value) string {
+	if len(vals) == 0 {
+		return ""
+	}
+
+	return "\nCancelled:\n" + valuesList(vals)
+}
+
+func idsString(ids []uint64) string {
+	parts := make([]string, 0, len(ids))
+	for _, ID := range ids {
+		parts = append(parts, fmt.Sprintf("%d", ID))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+type momentumKey struct {
+	ID     int64
+	Symbol string
+	Window time.Duration
+}
+
+// momentumLog is time of the last momentum by user, symbol and window, so the same move isn't sent twice.
+type momentumLog struct {
+	m    sync.Mutex
+	sent map[momentumKey]time.Time
+}
+
+func newMomentumLog() *momentumLog {
+	return &momentumLog{sent: map[momentumKey]time.Time{}}
+}
+
+// isSent return true if momentum was sent within its window before t.
+func (l *momentumLog) isSent(key momentumKey, t time.Time) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+	last, exists := l.sent[key]
+
+	return exists && (t.Sub(last) < key.Window)
+}
+
+// add remember momentum sent at t and forget momentums which windows are over.
+func (l *momentumLog) add(key momentumKey, t time.Time) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	for k, last := range l.sent {
+		if t.Sub(last) >= k.Window {
+			delete(l.sent, k)
+		}
+	}
+	l.sent[key] = t
+}
+
+func checkMomentum(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram, sent *momentumLog) {
+	ids := dbH.Users()
+	for _, ID := range ids {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			break
+		}
+		us := userSettings(dbH, ID)
+		if us.Momentum.Disabled {
+			continue
+		}
+		symbs := quoter.GetAllowedSymbols()
+		for _, symb := range symbs {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				break
+			}
+			now := time.Now()
+			if us.IsMuted(symb, now) {
+				continue
+			}
+			for _, r := range us.MomentumRules(symb) {
+				key := momentumKey{ID: ID, Symbol: r.Symbol, Window: r.Window}
+				// the same move must not be sent again within its window
+				if sent.isSent(key, now) {
+					continue
+				}
+				q, err := qHolder.GetWindowQuote(symb, now, r.Window)
+				if errors.Is(err, quoter.ErrNoQuote) {
+					continue
+				}
+				if err != nil {
+					log.Printf("Can't get quotes to check momentum: %d. %q. %v", ID, symb, err)
+					continue
+				}
+				diff := q.Close - q.Open
+				points := quoter.ToPoints(symb, math.Abs(diff))
+				if points < r.Points {
+					continue
+				}
+				sent.add(key, now)
+				go sendMomentum(dbH, tlg, ID, r, *q, points, us.Priority(db.NotificationMomentum))
+			}
+		}
+	}
+}
+
+func sendMomentum(dbH *db.DB, tlg *telegram.Telegram, ID int64, r db.MomentumRule, q quoter.Quote, points int64, p db.Priority) {
+	msg := fmt.Sprintf(
+		"Diff: %s %s - %d (%.5f)\tPrevious: %.5f\tCurrent: %.5f",
+		r.Symbol,
+		windowString(r.Window),
+		points,
+		q.Close-q.Open,
+		q.Open,
+		q.Close,
+	)
+	if err := notify(dbH, tlg, ID, telegram.Answer{Text: msg}, p); err != nil {
+		log.Printf("Can't send alert: %d. %q. %v", ID, msg, err)
+		return
+	}
+	log.Printf("Sent alert: %d. %q", ID, msg)
+}
+
+// windowString return window as 5m or 1h.
+func windowString(w time.Duration) string {
+	if w%time.Hour == 0 {
+		return fmt.Sprintf("%dh", w/time.Hour)
+	}
+
+	return fmt.Sprintf("%dm", w/time.Minute)
+}
+
+func processMomentum(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	if cmd.Action != commands.ActionList {
+		switch {
+		case (cmd.MomentumRule != nil) && (cmd.Action == commands.ActionDelete):
+			if !us.Momentum.RemoveRule(cmd.MomentumRule.Symbol, cmd.MomentumRule.Window) {
+				return &telegram.Answer{Text: "Rule not found"}, nil
+			}
+		case cmd.MomentumRule != nil:
+			us.Momentum.SetRule(*cmd.MomentumRule)
+			us.Momentum.Disabled = false
+		default:
+			us.Momentum.Disabled = cmd.Action == commands.ActionDelete
+		}
+		if err := dbH.SetSettings(msg.Chat.ID, us); err != nil {
+			return nil, fmt.Errorf("Can't set momentum: %w", err)
+		}
+	}
+
+	return &telegram.Answer{Text: momentumString(us.Momentum)}, nil
+}
+
+func momentumString(m db.Momentum) string {
+	state := "Momentum is enabled"
+	if m.Disabled {
+		state = "Momentum is disabled"
+	}
+	if len(m.Rules) == 0 {
+		return state + "\nDefault: * 5m 50 (BTCUSD 500)"
+	}
+	lines := make([]string, 0, len(m.Rules)+1)
+	lines = append(lines, state)
+	for _, r := range m.Rules {
+		lines = append(lines, fmt.Sprintf("%s %s %d", r.Symbol, windowString(r.Window), r.Points))
+	}
+
+	return strings.Join(lines, "\n")
+}
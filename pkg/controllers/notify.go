@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/telegram"
+)
+
+const timeFormat = "2006-01-02 15:04 MST"
+
+// userSettings return settings of user or defaults if they can't be loaded.
+func userSettings(dbH *db.DB, ID int64) db.UserSettings {
+	us, err := dbH.GetSettings(ID)
+	if err != nil {
+		if !errors.Is(err, db.ErrUserNotFound) {
+			log.Printf("[ERROR] Can't get settings: %d. %v", ID, err)
+		}
+		return db.UserSettings{}
+	}
+
+	return *us
+}
+
+// formatTime show time in timezone of user.
+func formatTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(timeFormat)
+}
+
+// notify send notification which is not answer to command.
+// Low priority is sent without sound, high priority is sent with Ack button and repeated until it is acknowledged.
+// Other notifications are stored till end of digest window if user set it.
+func notify(dbH *db.DB, tlg *telegram.Telegram, ID int64, answer telegram.Answer, p db.Priority) error {
+	switch p {
+	case db.PriorityLow:
+		answer.DisableNotification = true
+	case db.PriorityHigh:
+		return notifyHigh(dbH, tlg, ID, answer)
+	}
+	w := userSettings(dbH, ID).DigestWindow
+	if w <= 0 {
+		return deliver(dbH, tlg, ID, answer)
+	}
+	if err := dbH.AddDigest(ID, answerNotification(answer, time.Now())); err != nil {
+		return fmt.Errorf("Can't add notification to digest: %w", err)
+	}
+
+	return nil
+}
+
+// deliver send notification now. During quiet hours it is deferred or sent without sound.
+func deliver(dbH *db.DB, tlg *telegram.Telegram, ID int64, answer telegram.Answer) error {
+	us := userSettings(dbH, ID)
+	now := time.Now()
+	if us.IsQuiet(now) {
+		if us.Quiet.Mode == db.QuietDigest {
+			return dbH.Defer(ID, answerNotification(answer, now))
+		}
+		answer.DisableNotification = true
+	}
+
+	return tlg.SendMessage(ID, 0, answer)
+}
+
+// ProcessDeferred send notifications deferred during quiet hours when they are over.
+func ProcessDeferred(ctx context.Context, dbH *db.DB, tlg *telegram.Telegram) {
+	log.Printf("Deferred notifications controller started")
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			for _, ID := range dbH.Users() {
+				us := userSettings(dbH, ID)
+				if us.IsQuiet(t) {
+					continue
+				}
+				sendDeferred(dbH, tlg, ID, us.Location())
+			}
+		}
+	}
+}
+
+// sendDeferred send notifications deferred during quiet hours, they are removed only after they are sent.
+// Not sent notifications are sent by the next tick.
+func sendDeferred(dbH *db.DB, tlg *telegram.Telegram, ID int64, loc *time.Location) {
+	ns := dbH.Deferred(ID)
+	if len(ns) == 0 {
+		return
+	}
+	for _, m := range deferredMessages(ns, loc) {
+		if err := tlg.SendMessage(ID, 0, m.answer); err != nil {
+			log.Printf("[ERROR] Can't send deferred notifications to %d. %v. %s", ID, err, m.answer.Text)
+			return
+		}
+		log.Printf("[INFO] Deferred notifications sent %d. %s", ID, m.answer.Text)
+		if err := dbH.RemoveDeferred(ID, m.count); err != nil {
+			log.Printf("[ERROR] Can't remove sent deferred notifications: %d. %v", ID, err)
+			return
+		}
+	}
+}
+
+// deferredMessages join deferred notifications prefixed by local time to messages with their buttons.
+func deferredMessages(ns []db.Notification, loc *time.Location) []digestMessage {
+	answers := make([]telegram.Answer, 0, len(ns))
+	texts := make([]string, 0, len(ns))
+	for i, n := range ns {
+		answers = append(answers, notificationAnswer(n))
+		texts = append(texts, fmt.Sprintf("%d. %s %s", i+1, n.Time.In(loc).Format("15:04"), n.Text))
+	}
+
+	return joinAnswers("During quiet hours:", answers, texts, "\n")
+}
+
+func processTimezone(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	if cmd.Action == commands.ActionList {
+		return &telegram.Answer{Text: fmt.Sprintf("Timezone: %s. Now: %s", us.Location(), formatTime(time.Now(), us.Location()))}, nil
+	}
+	us.Timezone = cmd.Timezone
+	if err := dbH.SetSettings(msg.Chat.ID, us); err != nil {
+		return nil, fmt.Errorf("Can't set timezone: %w", err)
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Timezone: %s. Now: %s", us.Location(), formatTime(time.Now(), us.Location()))}, nil
+}
+
+func processQuiet(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	switch cmd.Action {
+	case commands.ActionList:
+		if us.Quiet == nil {
+			return &telegram.Answer{Text: "No quiet hours"}, nil
+		}
+
+		return &telegram.Answer{Text: fmt.Sprintf("Quiet hours: %s (%s)", us.Quiet, us.Location())}, nil
+	case commands.ActionDelete:
+		us.Quiet = nil
+	default:
+		us.Quiet = cmd.Quiet
+	}
+	if err := dbH.SetSettings(msg.Chat.ID, us); err != nil {
+		return nil, fmt.Errorf("Can't set quiet hours: %w", err)
+	}
+	if us.Quiet == nil {
+		return &telegram.Answer{Text: "Quiet hours disabled"}, nil
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Quiet hours: %s (%s)", us.Quiet, us.Location())}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,7 +32,7 @@ func ProcessPatterns(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tl
 		timeframeHour: isNewH1Bar,
 		timeframeDay:  func(t time.Time) bool { return true },
 	}
-	getQuotes := map[string]func(string, int) (*quoter.Quote, error){
+	getQuotes := map[string]func(string, time.Time) (*quoter.Quote, error){
 		timeframeHour: qHolder.GetQuoteByHour,
 		timeframeDay:  qHolder.GetQuoteByDay,
 	}
@@ -59,13 +60,14 @@ func ProcessPatterns(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tl
 				}
 				checked[tf] = timeToCheck
 				symbols := quoter.GetAllowedSymbols()
-				var msgs []string
+				// msgs is pattern message by symbol
+				msgs := map[string]string{}
 				for _, sym := range symbols {
+					barTime := t.Add(-time.Hour)
 					if tf == timeframeDay {
-						tt := quoter.PreviousDay(sym, t)
-						timeToCheck = tt.YearDay()
+						barTime = quoter.PreviousDay(sym, t)
 					}
-					q, err := getQuotes[tf](sym, timeToCheck)
+					q, err := getQuotes[tf](sym, barTime)
 					if err != nil {
 						if tf == timeframeDay {
 							checked[tf] = -1
@@ -76,22 +78,24 @@ func ProcessPatterns(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tl
 					if p == nil {
 						continue
 					}
-					msgs = append(
-						msgs,
-						fmt.Sprintf(
-							"%s - %s (%s)",
-							strings.ToUpper(sym),
-							string(p.Name),
-							string(p.Sentiment),
-						),
+					msgs[sym] = fmt.Sprintf(
+						"%s - %s (%s)",
+						strings.ToUpper(sym),
+						string(p.Name),
+						string(p.Sentiment),
 					)
 				}
 				if len(msgs) == 0 {
 					continue
 				}
-				answer := telegram.Answer{Text: tf + "\n" + strings.Join(msgs, "\n")}
 				for _, ID := range users {
-					if err := tlg.SendMessage(ID, 0, answer); err != nil {
+					us := userSettings(dbH, ID)
+					lines := userPatterns(us, msgs, t)
+					if len(lines) == 0 {
+						continue
+					}
+					answer := telegram.Answer{Text: tf + "\n" + strings.Join(lines, "\n")}
+					if err := notify(dbH, tlg, ID, answer, us.Priority(db.NotificationPattern)); err != nil {
 						log.Printf("[ERROR] Can't send pattern to %d. %v. %s", ID, err, answer.Text)
 					}
 					log.Printf("[INFO] Patterns sent %d. %s", ID, answer.Text)
@@ -103,6 +107,20 @@ func ProcessPatterns(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tl
 	}
 }
 
+// userPatterns return sorted pattern messages of symbols which are not muted by user.
+func userPatterns(us db.UserSettings, msgs map[string]string, t time.Time) []string {
+	var lines []string
+	for sym, msg := range msgs {
+		if us.IsMuted(sym, t) {
+			continue
+		}
+		lines = append(lines, msg)
+	}
+	sort.Strings(lines)
+
+	return lines
+}
+
 func isNewH1Bar(t time.Time) bool {
 	m := t.Minute()
 
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+func processAddPercent(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for percent change: %w", err)
+	}
+	val := *cmd.Value
+	pc := *val.Percent
+	if pc.Reference == db.ReferenceSet {
+		pc.Price = q.Close
+	}
+	val.Percent = &pc
+	setCreated(&val, q.Close, db.OriginManual)
+	added := []db.Value{val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
+		return nil, fmt.Errorf("Can't add value: %w", err)
+	}
+	val.ID = added[0].ID
+	if val.ID == 0 {
+		return &telegram.Answer{Text: "Percent alert already exists: " + val.String()}, nil
+	}
+
+	return &telegram.Answer{
+		Text: fmt.Sprintf(
+			"Added: %s \nChange: %.2f%% \nCurrent: %.5f",
+			valueLine(val),
+			db.ChangePercent(percentReference(val, q), q.Close),
+			q.Close,
+		),
+	}, nil
+}
+
+// percentReference return price from which change is calculated.
+func percentReference(val db.Value, q *quoter.Quote) float64 {
+	if val.Percent == nil {
+		return 0
+	}
+	if val.Percent.Reference == db.ReferenceOpen {
+		return q.Open
+	}
+
+	return val.Percent.Price
+}
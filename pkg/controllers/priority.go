@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/telegram"
+)
+
+const (
+	// ackInterval is time between repeats of not acknowledged notification.
+	ackInterval = 5 * time.Minute
+	// maxAckRepeats limits repeats of notification which is never acknowledged.
+	maxAckRepeats = 12
+)
+
+// notifyHigh send notification with Ack button and store it to repeat until it is acknowledged.
+// During quiet hours it is sent when they are over in digest mode or without sound in silent mode.
+func notifyHigh(dbH *db.DB, tlg *telegram.Telegram, ID int64, answer telegram.Answer) error {
+	us := userSettings(dbH, ID)
+	now := time.Now()
+	quiet := us.IsQuiet(now)
+	if quiet && (us.Quiet.Mode == db.QuietDigest) {
+		if _, err := dbH.AddAck(ID, answerNotification(answer, now), time.Time{}); err != nil {
+			return fmt.Errorf("Can't store notification to acknowledge: %w", err)
+		}
+
+		return nil
+	}
+	key, err := dbH.AddAck(ID, answerNotification(answer, now), now)
+	if err != nil {
+		return fmt.Errorf("Can't store notification to acknowledge: %w", err)
+	}
+	answer = withAck(answer, key)
+	answer.DisableNotification = answer.DisableNotification || quiet
+	if err := tlg.SendMessage(ID, 0, answer); err != nil {
+		if _, ackErr := dbH.Acknowledge(ID, []uint64{key}); ackErr != nil {
+			log.Printf("[ERROR] Can't remove not sent notification: %d. %v", ID, ackErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// withAck add Ack button to inline keyboard of answer.
+func withAck(answer telegram.Answer, key uint64) telegram.Answer {
+	var rows [][]telegram.InlineKeyboardButton
+	if answer.InlineKeyboard != nil {
+		rows = append(rows, answer.InlineKeyboard.InlineKeyboard...)
+	}
+	rows = append(rows, []telegram.InlineKeyboardButton{
+		{Text: "Ack", CallbackData: fmt.Sprintf("%s %d", commands.Ack, key)},
+	})
+	answer.InlineKeyboard = &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+
+	return answer
+}
+
+// withoutAck return keyboard without Ack buttons, nil if nothing is left.
+func withoutAck(markup *telegram.InlineKeyboardMarkup) *telegram.InlineKeyboardMarkup {
+	if markup == nil {
+		return nil
+	}
+	var rows [][]telegram.InlineKeyboardButton
+	for _, row := range markup.InlineKeyboard {
+		var kept []telegram.InlineKeyboardButton
+		for _, btn := range row {
+			if !isAckCallback(btn.CallbackData) {
+				kept = append(kept, btn)
+			}
+		}
+		if len(kept) > 0 {
+			rows = append(rows, kept)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func isAckCallback(data string) bool {
+	cmd, err := commands.CommandFromString(data)
+
+	return (err == nil) && (cmd == commands.Ack)
+}
+
+// ProcessAcks repeat high priority notifications until they are acknowledged, nothing is sent during quiet hours.
+func ProcessAcks(ctx context.Context, dbH *db.DB, tlg *telegram.Telegram) {
+	log.Printf("Acknowledgement controller started")
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			for _, ID := range dbH.Users() {
+				if userSettings(dbH, ID).IsQuiet(t) {
+					continue
+				}
+				for _, a := range dbH.Acks(ID) {
+					repeatAck(dbH, tlg, ID, a, t)
+				}
+			}
+		}
+	}
+}
+
+// repeatAck send notification which is due at time t, the first send after quiet hours is not counted as repeat.
+func repeatAck(dbH *db.DB, tlg *telegram.Telegram, ID int64, a db.Ack, t time.Time) {
+	if !a.Sent.IsZero() && (t.Sub(a.Sent) < ackInterval) {
+		return
+	}
+	answer := withAck(notificationAnswer(a.Notification), a.ID)
+	if !a.Sent.IsZero() {
+		a.Repeats++
+		answer.Text = fmt.Sprintf("Repeat %d/%d: %s", a.Repeats, maxAckRepeats, answer.Text)
+	}
+	if err := tlg.SendMessage(ID, 0, answer); err != nil {
+		log.Printf("[ERROR] Can't repeat notification to %d. %v. %s", ID, err, answer.Text)
+		return
+	}
+	log.Printf("[INFO] Notification repeated %d. %s", ID, answer.Text)
+	a.Sent = t
+	if a.Repeats >= maxAckRepeats {
+		if _, err := dbH.Acknowledge(ID, []uint64{a.ID}); err != nil {
+			log.Printf("[ERROR] Can't remove repeated notification: %d. %v", ID, err)
+		}
+		return
+	}
+	if err := dbH.UpdateAck(ID, a); err != nil {
+		log.Printf("[ERROR] Can't save repeated notification: %d. %v", ID, err)
+	}
+}
+
+func processAck(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	n, err := dbH.Acknowledge(msg.Chat.ID, cmd.IDs)
+	if err != nil {
+		return nil, fmt.Errorf("Can't acknowledge: %w", err)
+	}
+	if n == 0 {
+		return &telegram.Answer{Text: "Nothing to acknowledge"}, nil
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Acknowledged: %d", n)}, nil
+}
+
+func processPriority(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	if cmd.Action != commands.ActionList {
+		if us.Priorities == nil {
+			us.Priorities = map[db.NotificationKind]db.Priority{}
+		}
+		us.Priorities[cmd.Kind] = cmd.Priority
+		if err := dbH.SetSettings(msg.Chat.ID, us); err != nil {
+			return nil, fmt.Errorf("Can't set priority: %w", err)
+		}
+	}
+	kinds := []db.NotificationKind{db.NotificationLevel, db.NotificationMomentum, db.NotificationPattern}
+	lines := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, us.Priority(k)))
+	}
+
+	return &telegram.Answer{Text: "Priorities:\n" + strings.Join(lines, "\n")}, nil
+}
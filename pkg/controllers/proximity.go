@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+// proximityDistance return price distance of warning before level.
+func proximityDistance(qHolder *quoter.Holder, val db.Value, price float64) (float64, error) {
+	d, err := offsetDistance(qHolder, val.Key, price, val.Proximity.Distance)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Abs(d), nil
+}
+
+// initProximity mark price which is already near level, so warning is sent on the next approach only.
+func initProximity(qHolder *quoter.Holder, val *db.Value, price float64) {
+	if (val.Proximity == nil) || (price == 0) {
+		return
+	}
+	p := *val.Proximity
+	val.Proximity = &p
+	d, err := proximityDistance(qHolder, *val, price)
+	if err != nil {
+		log.Printf("Can't init proximity: %q. %v", val.String(), err)
+		return
+	}
+	val.Approached(price, d)
+}
+
+// approaching update proximity state of value and return true if price came near level.
+func approaching(qHolder *quoter.Holder, val *db.Value, price float64) bool {
+	if val.Proximity == nil {
+		return false
+	}
+	d, err := proximityDistance(qHolder, *val, price)
+	if err != nil {
+		log.Printf("Can't get proximity distance: %q. %v", val.String(), err)
+		return false
+	}
+
+	return val.Approached(price, d)
+}
+
+func sendApproachAlert(dbH *db.DB, tlg *telegram.Telegram, ID int64, val db.Value, q quoter.Quote) {
+	msg := fmt.Sprintf(
+		"Approaching: %s.  \t  Current: %.5f. Distance: %d",
+		valueLine(val),
+		q.Close,
+		quoter.ToPoints(val.Key, math.Abs(q.Close-val.Value)),
+	)
+	if val.Note != "" {
+		msg += "\nNote: " + val.Note
+	}
+	answer := telegram.Answer{Text: msg, InlineKeyboard: snoozeKeyboard(val)}
+	if err := notify(dbH, tlg, ID, answer, userSettings(dbH, ID).AlertPriority(val)); err != nil {
+		log.Printf("Can't send approach alert: %d. %q. %v", ID, msg, err)
+		return
+	}
+	log.Printf("Sent approach alert: %d. %q", ID, msg)
+}
+
+// proximityString describe warning for lists.
+func proximityString(v db.Value) string {
+	if v.Proximity == nil {
+		return ""
+	}
+
+	return "near " + v.Proximity.Distance.String()
+}
@@ -16,25 +16,33 @@ import (
 func ProcessQuotes(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram) {
 	levelTicker := time.NewTicker(65 * time.Second)
 	defer levelTicker.Stop()
-	momentumTicker := time.NewTicker(5 * time.Minute)
+	momentumTicker := time.NewTicker(time.Minute)
 	defer momentumTicker.Stop()
+	backfillTicker := time.NewTicker(time.Hour)
+	defer backfillTicker.Stop()
 	log.Printf("Quotes controller started")
+	failures := newFailureLog()
+	momentums := newMomentumLog()
 	go qHolder.Update(ctx, 2)
+	go qHolder.Backfill(ctx, 1)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-levelTicker.C:
 			qHolder.Update(ctx, 2)
-			checkUsersLevelAlerts(ctx, dbH, qHolder, tlg)
+			checkUsersLevelAlerts(ctx, dbH, qHolder, tlg, failures)
 		case <-momentumTicker.C:
 			qHolder.Update(ctx, 2)
-			checkMomentum(ctx, dbH, qHolder, tlg)
+			checkMomentum(ctx, dbH, qHolder, tlg, momentums)
+		case <-backfillTicker.C:
+			// bars which failed to fetch are fetched again
+			go qHolder.Backfill(ctx, 1)
 		}
 	}
 }
 
-func checkUsersLevelAlerts(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram) {
+func checkUsersLevelAlerts(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram, failures *failureLog) {
 	ids := dbH.Users()
 	for _, ID := range ids {
 		select {
@@ -44,6 +52,11 @@ func checkUsersLevelAlerts(ctx context.Context, dbH *db.DB, qHolder *quoter.Hold
 			break
 		}
 		values := dbH.List(ID)
+		failures.prune(ID, values)
+		us := userSettings(dbH, ID)
+		var checked []db.Value
+		// fired groups are cancelled, so other values of them must not be triggered in the same check
+		fired := map[uint64]bool{}
 		for _, val := range values {
 			select {
 			case <-ctx.Done():
@@ -51,34 +64,230 @@ func checkUsersLevelAlerts(ctx context.Context, dbH *db.DB, qHolder *quoter.Hold
 			default:
 				break
 			}
+			now := time.Now()
+			if val.IsExpired(now) || fired[val.Group] {
+				continue
+			}
 			q, err := qHolder.GetCurrentQuote(val.Key)
 			if err != nil {
 				log.Printf("Can't get quotes to check levels: %d. %q. %v", ID, val.Key, err)
 				continue
 			}
-			if !val.IsAlert(q.Close) {
+			before := val.Clone()
+			rearmed, err := rearmDynamic(qHolder, &val, q.Close, now)
+			if err != nil {
+				// level of the previous session must not be triggered
+				if failures.isNew(ID, val.ID, "dynamic "+sessionStart(now).Format("2006-01-02")) {
+					log.Printf("Can't resolve named level: %d. %q. %v", ID, val.String(), err)
+				}
 				continue
 			}
-			go func(ID int64, val db.Value, p float64) {
-				msg := fmt.Sprintf("Alert: %s.  \t  Current: %.5f", val.String(), p)
-				if err := tlg.SendMessage(ID, 0, telegram.Answer{Text: msg}); err != nil {
-					log.Printf("Can't send alert: %d. %q. %v", ID, msg, err)
-					return
+			if rearmed {
+				failures.reset(ID, val.ID)
+			}
+			triggered, err := isTriggered(qHolder, &val, q)
+			if err != nil {
+				if failures.isNew(ID, val.ID, err.Error()) {
+					log.Printf("Can't check value: %d. %q. %v", ID, val.String(), err)
+				}
+			} else {
+				failures.reset(ID, val.ID)
+			}
+			approached := approaching(qHolder, &val, q.Close) && !triggered
+			// price is saved only if it moved to other side of level, state of conditions is saved only if check changed it
+			changed := !val.Checked || (priceSide(before, q, before.LastPrice) != priceSide(val, q, q.Close)) || val.IsCheckStateChanged(before)
+			if val.Disarmed && val.IsRearmed(q.Close, quoter.FromPoints(val.Key, val.Rearm)) {
+				val.Disarmed = false
+				changed = true
+			}
+			// snoozed value keeps checked price, so it is triggered by new cross after snooze
+			snoozed := us.IsSnoozed(val.ID, now)
+			if !triggered || !val.CanFire(now) || snoozed {
+				if approached && val.CanFire(now) && !snoozed {
+					go sendApproachAlert(dbH, tlg, ID, val, *q)
 				}
-				log.Printf("Sent alert: %d. %q", ID, msg)
-				if err := dbH.DeleteValue(ID, val); err != nil {
-					log.Printf("Can't delete: %d. %q. %v", ID, val.String(), err)
-					return
+				if changed {
+					val.SetLastPrice(q.Close)
+					checked = append(checked, val)
 				}
-				if val.Delta > 0 {
-					if err := ensureDeltaValues(dbH, qHolder, ID, val.Key, val.Delta); err != nil {
-						log.Printf("Can't add delta values: %d - %s", ID, val.Key)
-					}
+				continue
+			}
+			var cancelled []db.Value
+			if val.Group != 0 {
+				fired[val.Group] = true
+				// linked values are cancelled after alert is sent, so user always learns about it
+				cancelled = dbH.Linked(ID, val)
+				val.Group = 0
+			}
+			if val.Repeat {
+				val.Count++
+				val.LastFired = now
+				val.Disarmed = (val.Rearm > 0) && (val.Kind == db.KindLevel)
+				val.SetLastPrice(q.Close)
+				if val.Trailing != nil {
+					// repeated trailing alert starts to follow price from the trigger
+					val.Trailing.Extreme = q.Close
 				}
-				log.Printf("Deleted: %d. %q", ID, val.String())
-			}(ID, val, q.Close)
+				if !val.IsFinished() {
+					checked = append(checked, val)
+					go sendLevelAlert(dbH, qHolder, tlg, ID, val, *q, cancelled, false)
+					continue
+				}
+			}
+			if (val.Dynamic != nil) && !val.Repeat {
+				// named level is rearmed in the next session
+				val.Dynamic.Fired = true
+				val.SetLastPrice(q.Close)
+				checked = append(checked, val)
+				go sendLevelAlert(dbH, qHolder, tlg, ID, val, *q, cancelled, false)
+				continue
+			}
+			go sendLevelAlert(dbH, qHolder, tlg, ID, val, *q, cancelled, true)
+		}
+		if len(checked) > 0 {
+			if err := dbH.UpdateCheckState(ID, checked); err != nil {
+				log.Printf("Can't save checked prices: %d. %v", ID, err)
+			}
+		}
+	}
+}
+
+// priceSide return side of price to level of value: -1 below, 0 at or inside, 1 above.
+// Triggers depend on side of the last price only, so check is saved when side is changed.
+func priceSide(val db.Value, q *quoter.Quote, price float64) int {
+	low, high := val.Value, val.Value
+	switch {
+	case (val.Kind == db.KindRange) && (val.Range != nil):
+		low, high = val.Range.Low, val.Range.High
+	case (val.Kind == db.KindPercent) && (val.Percent != nil):
+		price = db.ChangePercent(percentReference(val, q), price)
+		low, high = -val.Percent.Percent, val.Percent.Percent
+	}
+	switch {
+	case price < low:
+		return -1
+	case price > high:
+		return 1
+	}
+
+	return 0
+}
+
+// isTriggered check condition of value with current quote.
+// State of value which depends on price (e.g. trailing stop) is updated.
+// Error is returned if condition can't be checked now, value isn't triggered then.
+func isTriggered(qHolder *quoter.Holder, val *db.Value, q *quoter.Quote) (bool, error) {
+	switch val.Kind {
+	case db.KindPercent:
+		return val.IsPercentReached(percentReference(*val, q), q.Close), nil
+	case db.KindTrailing:
+		return trail(qHolder, val, q.Close), nil
+	case db.KindRange:
+		return val.RangeEvent(q.Close) != "", nil
+	case db.KindRule:
+		return checkRule(qHolder, val)
+	case db.KindTouch:
+		return touched(val, q.Close), nil
+	}
+	if val.Confirm != nil {
+		return isCloseConfirmed(qHolder, val, time.Now())
+	}
+
+	return val.IsCrossed(q.Close), nil
+}
+
+// sendLevelAlert send alert and delete triggered value if it is not repeated anymore.
+// Linked values are cancelled before, they are listed in alert.
+func sendLevelAlert(dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram, ID int64, val db.Value, q quoter.Quote, cancelled []db.Value, remove bool) {
+	p := q.Close
+	msg := fmt.Sprintf(
+		"Alert: %s.  \t  Current: %.5f. Beyond: %d",
+		valueLine(val),
+		p,
+		quoter.ToPoints(val.Key, math.Abs(p-val.Value)),
+	)
+	if val.Kind == db.KindPercent {
+		msg = fmt.Sprintf("Alert: %s.  \t  Current: %.5f. Change: %s", valueLine(val), p, valueDistance(val, &q))
+	}
+	if (val.Kind == db.KindRule) && (val.Rule != nil) {
+		msg = "Alert: " + valueLine(val) + "." + ruleQuotesString(qHolder, val)
+	}
+	if (val.Kind == db.KindRange) && (val.Range != nil) {
+		msg = fmt.Sprintf(
+			"Alert: %s. %s.  \t  Current: %.5f. Beyond: %d",
+			valueLine(val),
+			rangeEventString(*val.Range, p),
+			p,
+			quoter.ToPoints(val.Key, val.Range.Distance(p)),
+		)
+	}
+	if val.Confirm != nil {
+		msg += fmt.Sprintf(". Bar %s closed: %.5f", val.Confirm.Timeframe, val.Confirm.LastClose)
+	}
+	if val.Touch != nil {
+		msg += ". " + touchAlertString(*val.Touch)
+	}
+	if val.Repeat {
+		msg += fmt.Sprintf(". Triggered: %d", val.Count)
+		if val.MaxCount > 0 {
+			msg += fmt.Sprintf("/%d", val.MaxCount)
+		}
+	}
+	if val.Note != "" {
+		msg += "\nNote: " + val.Note
+	}
+	if len(val.Tags) > 0 {
+		msg += "\nTags: #" + strings.Join(val.Tags, " #")
+	}
+	if !val.CreatedAt.IsZero() && (val.CreatedPrice != 0) {
+		msg += fmt.Sprintf(
+			"\nTravelled: %d points in %s (%s)",
+			quoter.ToPoints(val.Key, math.Abs(p-val.CreatedPrice)),
+			formatDuration(time.Since(val.CreatedAt)),
+			val.Origin,
+		)
+	}
+	msg += cancelledString(cancelled)
+	answer := telegram.Answer{Text: msg}
+	if !remove {
+		answer.InlineKeyboard = snoozeKeyboard(val)
+	}
+	if err := notify(dbH, tlg, ID, answer, userSettings(dbH, ID).AlertPriority(val)); err != nil {
+		log.Printf("Can't send alert: %d. %q. %v", ID, msg, err)
+		return
+	}
+	log.Printf("Sent alert: %d. %q", ID, msg)
+	if len(cancelled) > 0 {
+		if err := dbH.DeleteValues(ID, cancelled); err != nil {
+			log.Printf("Can't cancel linked values: %d. %q. %v", ID, val.String(), err)
+		}
+	}
+	if !remove {
+		return
+	}
+	if _, err := dbH.DeleteValue(ID, val); err != nil {
+		log.Printf("Can't delete: %d. %q. %v", ID, val.String(), err)
+		return
+	}
+	if val.Delta > 0 {
+		if err := ensureDeltaValues(dbH, qHolder, ID, val.Key, val.Delta); err != nil {
+			log.Printf("Can't add delta values: %d - %s", ID, val.Key)
 		}
 	}
+	log.Printf("Deleted: %d. %q", ID, val.String())
+}
+
+// formatDuration round duration to minutes: 26h5m.
+func formatDuration(d time.Duration) string {
+	s := d.Truncate(time.Minute).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
 }
 
 func ensureDeltaValues(dbH *db.DB, qHolder *quoter.Holder, ID int64, symb string, delta uint64) error {
@@ -86,76 +295,43 @@ func ensureDeltaValues(dbH *db.DB, qHolder *quoter.Holder, ID int64, symb string
 	if err != nil {
 		return err
 	}
-	prec := quoter.GetPrecision(symb)
-	d := quoter.FromPoints(symb, int64(delta))
-	levels := []db.Value{
-		{
-			Key:       symb,
-			Value:     q.Close + d,
-			Precision: prec,
-			Type:      db.BelowCurrent,
-			Delta:     delta,
-		},
-		{
-			Key:       symb,
-			Value:     q.Close - d,
-			Precision: prec,
-			Type:      db.AboveCurrent,
-			Delta:     delta,
-		},
-	}
-	if err := dbH.Add(ID, levels); err != nil {
+	if err := dbH.AddGroup(ID, deltaValues(symb, q.Close, delta)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func checkMomentum(ctx context.Context, dbH *db.DB, qHolder *quoter.Holder, tlg *telegram.Telegram) {
-	ids := dbH.Users()
-	for _, ID := range ids {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			break
-		}
-		symbs := quoter.GetAllowedSymbols()
-		for _, symb := range symbs {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				break
-			}
-			qs, err := qHolder.GetQuote(symb)
-			if err != nil {
-				log.Printf("Can't get quotes to check momentum: %d. %q. %v", ID, symb, err)
-				continue
-			}
-			diff := qs.Current.Close - qs.Previous.Close
-			points := quoter.ToPoints(symb, math.Abs(diff))
-			if strings.EqualFold(symb, "btcusd") && (points < 500) {
-				continue
-			}
-			if points < 50 {
-				continue
-			}
-			go func(ID int64, symb string, diff float64, points int64, qs *quoter.Quotes) {
-				msg := fmt.Sprintf(
-					"Diff: %s - %d (%.5f)\tPrevious: %.5f\tCurrent: %.5f",
-					symb,
-					points,
-					diff,
-					qs.Previous.Close,
-					qs.Current.Close,
-				)
-				if err := tlg.SendMessage(ID, 0, telegram.Answer{Text: msg}); err != nil {
-					log.Printf("Can't send alert: %d. %q. %v", ID, msg, err)
-					return
-				}
-				log.Printf("Sent alert: %d. %q", ID, msg)
-			}(ID, symb, diff, points, qs)
-		}
+// deltaValues return pair of levels around price, they must be added as group.
+func deltaValues(symb string, price float64, delta uint64) []db.Value {
+	prec := quoter.GetPrecision(symb)
+	d := quoter.FromPoints(symb, int64(delta))
+	now := time.Now()
+
+	return []db.Value{
+		{
+			Key:          symb,
+			Value:        price + d,
+			Precision:    prec,
+			Type:         db.BelowCurrent,
+			Delta:        delta,
+			LastPrice:    price,
+			Checked:      true,
+			CreatedAt:    now,
+			CreatedPrice: price,
+			Origin:       db.OriginDelta,
+		},
+		{
+			Key:          symb,
+			Value:        price - d,
+			Precision:    prec,
+			Type:         db.AboveCurrent,
+			Delta:        delta,
+			LastPrice:    price,
+			Checked:      true,
+			CreatedAt:    now,
+			CreatedPrice: price,
+			Origin:       db.OriginDelta,
+		},
 	}
 }
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"fmt"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+func processAddRange(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for range: %w", err)
+	}
+	val := *cmd.Value
+	inside := val.Range.Contains(q.Close)
+	if (val.Range.Mode == db.RangeExit) && !inside {
+		return &telegram.Answer{Text: fmt.Sprintf("Price is already outside of range: %s \nCurrent: %.5f", val.String(), q.Close)}, nil
+	}
+	if (val.Range.Mode == db.RangeEnter) && inside {
+		return &telegram.Answer{Text: fmt.Sprintf("Price is already inside of range: %s \nCurrent: %.5f", val.String(), q.Close)}, nil
+	}
+	setCreated(&val, q.Close, db.OriginManual)
+	added := []db.Value{val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
+		return nil, fmt.Errorf("Can't add value: %w", err)
+	}
+	val.ID = added[0].ID
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \nCurrent: %.5f", valueLine(val), q.Close)}, nil
+}
+
+// rangeEventString describe range event for alert by price after the event.
+func rangeEventString(r db.Range, price float64) string {
+	switch {
+	case price > r.High:
+		return "Breakout up"
+	case price < r.Low:
+		return "Breakout down"
+	}
+
+	return "Entered"
+}
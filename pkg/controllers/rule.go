@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/rules"
+	"fx_alert/pkg/telegram"
+)
+
+func processRule(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	switch cmd.Action {
+	case commands.ActionAdd:
+		return processAddRule(dbH, qHolder, msg, cmd)
+	case commands.ActionDelete:
+		val, err := dbH.Get(msg.Chat.ID, cmd.Value.ID)
+		if (err != nil) || (val.Kind != db.KindRule) {
+			return &telegram.Answer{Text: fmt.Sprintf("Rule not found: %d", cmd.Value.ID)}, nil
+		}
+		cancelled, err := dbH.DeleteValue(msg.Chat.ID, *val)
+		if err != nil {
+			return nil, fmt.Errorf("Can't delete rule: %w", err)
+		}
+
+		return &telegram.Answer{Text: "Deleted: " + valueLine(*val) + cancelledString(cancelled)}, nil
+	}
+
+	return processListRules(dbH, qHolder, msg)
+}
+
+func processAddRule(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	val := *cmd.Value
+	rule := *val.Rule
+	val.Rule = &rule
+	q, err := qHolder.GetCurrentQuote(val.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for rule: %w", err)
+	}
+	state := ""
+	result, err := evalRule(qHolder, val)
+	if err != nil {
+		state = fmt.Sprintf("Can't evaluate now: %v", err)
+	} else {
+		val.Rule.Active = result
+		state = fmt.Sprintf("Now: %v", result)
+	}
+	setCreated(&val, q.Close, db.OriginManual)
+	added := []db.Value{val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
+		return nil, fmt.Errorf("Can't add rule: %w", err)
+	}
+	val.ID = added[0].ID
+	if val.ID == 0 {
+		return &telegram.Answer{Text: "Rule already exists: " + val.String()}, nil
+	}
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \n%s", valueLine(val), state)}, nil
+}
+
+func processListRules(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message) (*telegram.Answer, error) {
+	var lines []string
+	loc := userSettings(dbH, msg.Chat.ID).Location()
+	vals := dbH.List(msg.Chat.ID)
+	sort.Slice(vals, func(i, j int) bool { return vals[i].ID < vals[j].ID })
+	for _, v := range vals {
+		if v.Kind != db.KindRule {
+			continue
+		}
+		state := ""
+		if result, err := evalRule(qHolder, v); err != nil {
+			state = fmt.Sprintf("error: %v", err)
+		} else {
+			state = fmt.Sprintf("now: %v", result)
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s) %s", valueLine(v), state, valueDetails(v, loc)))
+	}
+	if len(lines) == 0 {
+		return &telegram.Answer{Text: "No rules"}, nil
+	}
+
+	return &telegram.Answer{Text: strings.Join(lines, "\n")}, nil
+}
+
+// evalRule evaluate expression of rule value with current quotes.
+func evalRule(qHolder *quoter.Holder, val db.Value) (bool, error) {
+	if val.Rule == nil {
+		return false, fmt.Errorf("No rule: %s", val.String())
+	}
+	r, err := rules.Parse(val.Rule.Expr)
+	if err != nil {
+		return false, fmt.Errorf("Can't parse rule: %w", err)
+	}
+
+	return r.Eval(qHolder, time.Now())
+}
+
+// checkRule return true if rule became true, errors of evaluation keep the last result.
+func checkRule(qHolder *quoter.Holder, val *db.Value) (bool, error) {
+	result, err := evalRule(qHolder, *val)
+	if err != nil {
+		return false, fmt.Errorf("Can't evaluate rule: %w", err)
+	}
+
+	return val.SetRuleResult(result), nil
+}
+
+// ruleQuotesString return current prices of symbols of rule.
+func ruleQuotesString(qHolder *quoter.Holder, val db.Value) string {
+	r, err := rules.Parse(val.Rule.Expr)
+	if err != nil {
+		return ""
+	}
+	var prices []string
+	for _, symb := range r.Symbols() {
+		q, err := qHolder.GetCurrentQuote(symb)
+		if err != nil {
+			continue
+		}
+		prices = append(prices, fmt.Sprintf("%s: %.5f", symb, q.Close))
+	}
+	if len(prices) == 0 {
+		return ""
+	}
+
+	return "\nCurrent: " + strings.Join(prices, ", ")
+}
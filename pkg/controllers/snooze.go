@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+// snoozeButtonDuration is snooze of inline button of alert.
+const snoozeButtonDuration = "1h"
+
+func processSnooze(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if cmd.Action == commands.ActionList {
+		return processListSnoozes(dbH, msg)
+	}
+	val, err := dbH.Get(msg.Chat.ID, cmd.Value.ID)
+	if err != nil {
+		return &telegram.Answer{Text: fmt.Sprintf("Alert not found: %d", cmd.Value.ID)}, nil
+	}
+	if err := dbH.Snooze(msg.Chat.ID, val.ID, cmd.Until); err != nil {
+		return nil, fmt.Errorf("Can't snooze value: %w", err)
+	}
+	if cmd.Until.IsZero() {
+		return &telegram.Answer{Text: "Snooze cancelled: " + valueLine(*val)}, nil
+	}
+	loc := userSettings(dbH, msg.Chat.ID).Location()
+
+	return &telegram.Answer{Text: fmt.Sprintf("Snoozed till %s: %s", formatTime(cmd.Until, loc), valueLine(*val))}, nil
+}
+
+func processMute(dbH *db.DB, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if cmd.Action == commands.ActionList {
+		return processListSnoozes(dbH, msg)
+	}
+	symbol := strings.ToUpper(cmd.Value.Key)
+	if !quoter.IsValidSymbol(symbol) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	if err := dbH.Mute(msg.Chat.ID, symbol, cmd.Until); err != nil {
+		return nil, fmt.Errorf("Can't mute symbol: %w", err)
+	}
+	if cmd.Until.IsZero() {
+		return &telegram.Answer{Text: "Unmuted: " + symbol}, nil
+	}
+	loc := userSettings(dbH, msg.Chat.ID).Location()
+
+	return &telegram.Answer{Text: fmt.Sprintf("Muted till %s: %s", formatTime(cmd.Until, loc), symbol)}, nil
+}
+
+func processListSnoozes(dbH *db.DB, msg telegram.Message) (*telegram.Answer, error) {
+	us := userSettings(dbH, msg.Chat.ID)
+	loc := us.Location()
+	now := time.Now()
+	var lines []string
+	for valID, until := range us.Snoozed {
+		if !us.IsSnoozed(valID, now) {
+			continue
+		}
+		line := fmt.Sprintf("[%d]", valID)
+		if val, err := dbH.Get(msg.Chat.ID, valID); err == nil {
+			line = valueLine(*val)
+		}
+		lines = append(lines, fmt.Sprintf("Snoozed till %s: %s", formatTime(until, loc), line))
+	}
+	for symbol, until := range us.Muted {
+		if !us.IsMuted(symbol, now) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("Muted till %s: %s", formatTime(until, loc), symbol))
+	}
+	if len(lines) == 0 {
+		return &telegram.Answer{Text: "Nothing is snoozed or muted"}, nil
+	}
+	sort.Strings(lines)
+
+	return &telegram.Answer{Text: strings.Join(lines, "\n")}, nil
+}
+
+// snoozeKeyboard is inline button which snoozes stored value.
+func snoozeKeyboard(val db.Value) *telegram.InlineKeyboardMarkup {
+	if val.ID == 0 {
+		return nil
+	}
+
+	return &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{
+			{
+				{
+					Text:         "Snooze " + snoozeButtonDuration,
+					CallbackData: fmt.Sprintf("%s %d %s", commands.Snooze, val.ID, snoozeButtonDuration),
+				},
+			},
+		},
+	}
+}
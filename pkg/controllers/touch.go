@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+func processAddTouch(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for touches: %w", err)
+	}
+	val := *cmd.Value
+	tc := *val.Touch
+	tc.Above = q.Close > val.Value
+	tc.Near = math.Abs(q.Close-val.Value) <= quoter.FromPoints(val.Key, tc.Tolerance)
+	val.Touch = &tc
+	if (tc.Mode == db.TouchRetest) && tc.Near {
+		return &telegram.Answer{Text: fmt.Sprintf("Price is near level: %s \nCurrent: %.5f", val.String(), q.Close)}, nil
+	}
+	setCreated(&val, q.Close, db.OriginManual)
+	added := []db.Value{val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
+		return nil, fmt.Errorf("Can't add value: %w", err)
+	}
+	val.ID = added[0].ID
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \nCurrent: %.5f", valueLine(val), q.Close)}, nil
+}
+
+// touched update touches of value with price.
+func touched(val *db.Value, price float64) bool {
+	if val.Touch == nil {
+		return false
+	}
+
+	return val.Touched(price, quoter.FromPoints(val.Key, val.Touch.Tolerance), time.Now())
+}
+
+// touchString describe state of touches for lists and alerts.
+func touchString(v db.Value, loc *time.Location) string {
+	if v.Touch == nil {
+		return ""
+	}
+	var s string
+	switch v.Touch.Mode {
+	case db.TouchCount:
+		s = fmt.Sprintf("touches %d/%d", v.Touch.Count, v.Touch.Target)
+	case db.TouchRetest:
+		s = "waiting breakout"
+		if v.Touch.Broken {
+			s = "broken, waiting retest"
+		}
+	}
+	if !v.Touch.LastTouch.IsZero() {
+		s += " last " + formatTime(v.Touch.LastTouch, loc)
+	}
+
+	return s
+}
+
+// touchAlertString describe triggered touch.
+func touchAlertString(tc db.Touch) string {
+	if tc.Mode == db.TouchRetest {
+		return "Retest after breakout"
+	}
+
+	return fmt.Sprintf("Touches: %d", tc.Count)
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+
+	"fx_alert/pkg/commands"
+	"fx_alert/pkg/db"
+	"fx_alert/pkg/quoter"
+	"fx_alert/pkg/telegram"
+)
+
+func processAddTrailing(dbH *db.DB, qHolder *quoter.Holder, msg telegram.Message, cmd commands.CommandValue) (*telegram.Answer, error) {
+	if !quoter.IsValidSymbol(cmd.Value.Key) {
+		return invalidSymbolAnswer(cmd.Value.Key), nil
+	}
+	q, err := qHolder.GetCurrentQuote(cmd.Value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get quote for trailing: %w", err)
+	}
+	val := *cmd.Value
+	t := *val.Trailing
+	t.Extreme = q.Close
+	val.Trailing = &t
+	if trail(qHolder, &val, q.Close) {
+		return &telegram.Answer{Text: "Distance is too small: " + val.String()}, nil
+	}
+	if val.Value == 0 {
+		return nil, fmt.Errorf("Can't resolve trailing distance: %s", val.Trailing.Distance.String())
+	}
+	setCreated(&val, q.Close, db.OriginManual)
+	added := []db.Value{val}
+	if err := dbH.Add(msg.Chat.ID, added); err != nil {
+		return nil, fmt.Errorf("Can't add value: %w", err)
+	}
+	val.ID = added[0].ID
+
+	return &telegram.Answer{Text: fmt.Sprintf("Added: %s \nCurrent: %.5f", valueLine(val), q.Close)}, nil
+}
+
+// trail move trailing stop after price, distance in percents is taken from the best price.
+func trail(qHolder *quoter.Holder, val *db.Value, price float64) bool {
+	if val.Trailing == nil {
+		return false
+	}
+	ref := val.Trailing.Extreme
+	if ref == 0 {
+		ref = price
+	}
+	d, err := offsetDistance(qHolder, val.Key, ref, val.Trailing.Distance)
+	if err != nil {
+		log.Printf("Can't get trailing distance: %q. %v", val.String(), err)
+		return false
+	}
+
+	return val.Trail(price, d)
+}
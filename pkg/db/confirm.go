@@ -0,0 +1,36 @@
+package db
+
+import "time"
+
+// Timeframe is size of bar which close confirms level.
+type Timeframe string
+
+const (
+	TimeframeHour Timeframe = "h1"
+	TimeframeDay  Timeframe = "d1"
+)
+
+// Confirm is state of close-confirmed level: it is triggered by close of bar beyond level, not by touch.
+type Confirm struct {
+	Timeframe Timeframe
+	// LastBar is start of the last checked bar.
+	LastBar   time.Time
+	LastClose float64
+}
+
+// IsCloseBeyond check close of bar which started at barStart, every bar is checked once.
+func (v *Value) IsCloseBeyond(barStart time.Time, closeV float64) bool {
+	if (v.Confirm == nil) || !barStart.After(v.Confirm.LastBar) {
+		return false
+	}
+	prev := v.Confirm.LastClose
+	if prev == 0 {
+		prev = v.CreatedPrice
+	}
+	v.Confirm.LastBar = barStart
+	v.Confirm.LastClose = closeV
+	c := *v
+	c.LastPrice = prev
+
+	return c.IsCrossed(closeV)
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsCloseBeyond(t *testing.T) {
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	type tableData struct {
+		vt     ValueType
+		bar    time.Time
+		closes float64
+		expect bool
+	}
+
+	data := []tableData{
+		{vt: BelowCurrent, bar: start, closes: 1.1990, expect: false},
+		{vt: BelowCurrent, bar: start, closes: 1.2010, expect: false},
+		{vt: BelowCurrent, bar: start.Add(time.Hour), closes: 1.2010, expect: true},
+		{vt: CrossAny, bar: start, closes: 1.1990, expect: false},
+		{vt: CrossAny, bar: start.Add(time.Hour), closes: 1.2010, expect: true},
+		{vt: AboveCurrent, bar: start, closes: 1.2010, expect: false},
+		{vt: AboveCurrent, bar: start.Add(time.Hour), closes: 1.1990, expect: true},
+	}
+	v := Value{}
+	for i, d := range data {
+		if (i == 0) || (d.vt != data[i-1].vt) {
+			v = Value{Key: "EURUSD", Value: 1.2, Type: d.vt, CreatedPrice: 1.195, Confirm: &Confirm{Timeframe: TimeframeHour, LastBar: start.Add(-time.Hour)}}
+			if d.vt == AboveCurrent {
+				v.CreatedPrice = 1.205
+			}
+		}
+		if got := v.IsCloseBeyond(d.bar, d.closes); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type ValueType string
@@ -16,9 +19,58 @@ type ValueType string
 const (
 	AboveCurrent ValueType = "<"
 	BelowCurrent ValueType = ">"
+	// CrossAny is triggered when price crosses level in any direction.
+	CrossAny ValueType = "x"
 )
 
-var ErrUserNotFound = errors.New("User not found")
+// Kind is type of alert condition.
+type Kind string
+
+const (
+	// KindLevel is zero for values stored before kinds were introduced.
+	KindLevel    Kind = ""
+	KindPercent  Kind = "percent"
+	KindTrailing Kind = "trailing"
+	KindRange    Kind = "range"
+	KindTouch    Kind = "touch"
+	// KindRule is expression over quotes of several symbols, key is the first symbol of it.
+	KindRule Kind = "rule"
+)
+
+// TimeInForce is lifetime of value.
+type TimeInForce string
+
+const (
+	GoodTillCancelled TimeInForce = "gtc"
+	GoodTillDate      TimeInForce = "gtd"
+	EndOfDay          TimeInForce = "eod"
+)
+
+// Origin is how value was created.
+type Origin string
+
+const (
+	OriginManual Origin = "manual"
+	OriginDelta  Origin = "delta"
+	OriginGrid   Origin = "grid"
+	OriginImport Origin = "import"
+)
+
+// OffsetUnit is unit of distance relative to price.
+type OffsetUnit string
+
+const (
+	OffsetPoints  OffsetUnit = "p"
+	OffsetPercent OffsetUnit = "%"
+	OffsetATR     OffsetUnit = "atr"
+	// OffsetADR is percent of average daily range.
+	OffsetADR OffsetUnit = "%adr"
+)
+
+var (
+	ErrUserNotFound  = errors.New("User not found")
+	ErrValueNotFound = errors.New("Value not found")
+)
 
 type DB struct {
 	l    sync.RWMutex
@@ -29,6 +81,18 @@ type DB struct {
 type UserData struct {
 	Settings UserSettings
 	Levels   map[string][]Value
+	// NextID is ID of the next added value.
+	NextID uint64
+	// NextGroup is ID of the next group of linked values.
+	NextGroup uint64
+	// Deferred is notifications of quiet hours.
+	Deferred []Notification
+	// Digest is notifications of the current digest window.
+	Digest []Notification
+	// Acks is high priority notifications which are not acknowledged.
+	Acks []Ack
+	// NextAck is ID of the last stored Ack.
+	NextAck uint64
 }
 
 type Level struct {
@@ -38,14 +102,143 @@ type Level struct {
 
 type UserSettings struct {
 	Delta float64
+	// Snoozed is end of snooze by value ID, triggers of snoozed values are not sent.
+	Snoozed map[uint64]time.Time
+	// Muted is end of mute by symbol, momentum and patterns of muted symbols are not sent.
+	Muted map[string]time.Time
+	// Timezone is IANA name of user timezone, empty is UTC.
+	Timezone string
+	Quiet    *QuietHours
+	// DigestWindow is time during which notifications are merged into one message, 0 is disabled.
+	DigestWindow time.Duration
+	Priorities   map[NotificationKind]Priority
+	Momentum     Momentum
+}
+
+// clone copy settings, so stored maps can't be changed without lock.
+func (s UserSettings) clone() UserSettings {
+	if s.Snoozed != nil {
+		snoozed := make(map[uint64]time.Time, len(s.Snoozed))
+		for k, v := range s.Snoozed {
+			snoozed[k] = v
+		}
+		s.Snoozed = snoozed
+	}
+	if s.Muted != nil {
+		muted := make(map[string]time.Time, len(s.Muted))
+		for k, v := range s.Muted {
+			muted[k] = v
+		}
+		s.Muted = muted
+	}
+	if s.Priorities != nil {
+		priorities := make(map[NotificationKind]Priority, len(s.Priorities))
+		for k, v := range s.Priorities {
+			priorities[k] = v
+		}
+		s.Priorities = priorities
+	}
+	if s.Momentum.Rules != nil {
+		s.Momentum.Rules = append([]MomentumRule(nil), s.Momentum.Rules...)
+	}
+	if s.Quiet != nil {
+		q := *s.Quiet
+		s.Quiet = &q
+	}
+
+	return s
 }
 
 type Value struct {
+	// ID is unique per user, 0 if value is not stored yet.
+	ID        uint64
+	Kind      Kind
 	Key       string
 	Value     float64
 	Type      ValueType
 	Precision uint8
 	Delta     uint64
+	// LastPrice is price of the last check, it is valid if Checked is set.
+	LastPrice float64
+	// Checked is true if value was checked, 0 is valid price of spread.
+	Checked     bool
+	TimeInForce TimeInForce
+	// ExpiresAt is zero for good-till-cancelled values.
+	ExpiresAt time.Time
+	// Repeat keeps value armed after trigger.
+	Repeat   bool
+	Cooldown time.Duration
+	// MaxCount is maximum number of triggers of repeated value, 0 is unlimited.
+	MaxCount  uint
+	Count     uint
+	LastFired time.Time
+	// Rearm is distance in points which price must move back from level before next trigger.
+	Rearm    int64
+	Disarmed bool
+	Note     string
+	Tags     []string
+	// CreatedAt and CreatedPrice are zero for values created before metadata was stored.
+	CreatedAt    time.Time
+	CreatedPrice float64
+	Origin       Origin
+	// Group links values: trigger or deletion of one of them cancels the others, 0 is not linked.
+	Group    uint64
+	Percent  *PercentChange
+	Trailing *Trailing
+	Range    *Range
+	Rule     *Rule
+	Touch    *Touch
+	// Confirm is set for levels which are triggered by close of bar only.
+	Confirm *Confirm
+	// Proximity is set for levels with warning before trigger.
+	Proximity *Proximity
+	// Dynamic is set for named levels which are resolved every session: PDH, PDL, DO, WO.
+	Dynamic *Dynamic
+	// Priority is empty if priority of level notifications is used.
+	Priority Priority
+}
+
+// CanFire return true if repeated value is armed and cooldown is passed.
+func (v Value) CanFire(t time.Time) bool {
+	if v.Disarmed {
+		return false
+	}
+	if (v.Dynamic != nil) && v.Dynamic.Fired && !v.Repeat {
+		return false
+	}
+
+	return v.LastFired.IsZero() || (t.Sub(v.LastFired) >= v.Cooldown)
+}
+
+// IsRearmed return true if price moved back from level by distance.
+func (v Value) IsRearmed(currentV float64, distance float64) bool {
+	switch v.Type {
+	case BelowCurrent:
+		return currentV <= v.Value-distance
+	case AboveCurrent:
+		return currentV >= v.Value+distance
+	}
+
+	return math.Abs(currentV-v.Value) >= distance
+}
+
+// IsFinished return true if repeated value reached maximum number of triggers.
+func (v Value) IsFinished() bool {
+	return (v.MaxCount > 0) && (v.Count >= v.MaxCount)
+}
+
+func (v Value) IsExpired(t time.Time) bool {
+	return !v.ExpiresAt.IsZero() && !t.Before(v.ExpiresAt)
+}
+
+// Offset is signed distance from price, e.g. +50p, -0.3%, +2atr.
+type Offset struct {
+	Amount float64
+	Unit   OffsetUnit
+}
+
+func (o Offset) String() string {
+	return strconv.FormatFloat(o.Amount, 'f', -1, 64) + string(o.Unit)
 }
 
 func (v Value) IsAlert(currentV float64) bool {
@@ -64,7 +257,199 @@ func (v Value) IsAlert(currentV float64) bool {
 	return false
 }
 
+// IsCrossed return true if level is between the last checked price and current price.
+// Price which jumped over the level between checks is a cross too.
+func (v Value) IsCrossed(currentV float64) bool {
+	if !v.isChecked() {
+		// values stored before cross semantics keep touch behaviour
+		return v.IsAlert(currentV)
+	}
+	up := (v.LastPrice < v.Value) && (currentV >= v.Value)
+	down := (v.LastPrice > v.Value) && (currentV <= v.Value)
+	switch v.Type {
+	case BelowCurrent:
+		return up
+	case AboveCurrent:
+		return down
+	case CrossAny:
+		return up || down
+	}
+
+	return false
+}
+
+// SetLastPrice save price of check.
+func (v *Value) SetLastPrice(price float64) {
+	v.LastPrice = price
+	v.Checked = true
+}
+
+// isChecked return true if LastPrice is price of the last check.
+// Values stored before Checked flag are checked if LastPrice isn't 0.
+func (v Value) isChecked() bool {
+	return v.Checked || (v.LastPrice != 0)
+}
+
+// Clone copy value with its conditions, so stored values can't be changed without lock.
+func (v Value) Clone() Value {
+	if v.Tags != nil {
+		v.Tags = append([]string(nil), v.Tags...)
+	}
+	if v.Percent != nil {
+		p := *v.Percent
+		v.Percent = &p
+	}
+	if v.Trailing != nil {
+		t := *v.Trailing
+		v.Trailing = &t
+	}
+	if v.Range != nil {
+		r := *v.Range
+		v.Range = &r
+	}
+	if v.Rule != nil {
+		r := *v.Rule
+		v.Rule = &r
+	}
+	if v.Confirm != nil {
+		c := *v.Confirm
+		v.Confirm = &c
+	}
+	if v.Touch != nil {
+		t := *v.Touch
+		v.Touch = &t
+	}
+	if v.Proximity != nil {
+		p := *v.Proximity
+		v.Proximity = &p
+	}
+	if v.Dynamic != nil {
+		d := *v.Dynamic
+		v.Dynamic = &d
+	}
+
+	return v
+}
+
+// withCheckState return copy of value with state of check from checked one.
+// Settings of value (level of plain value, note, tags, options) are kept, they could be changed during check.
+func (v Value) withCheckState(checked Value) Value {
+	v = v.Clone()
+	v.LastPrice = checked.LastPrice
+	v.Checked = checked.Checked
+	v.Count = checked.Count
+	v.LastFired = checked.LastFired
+	v.Disarmed = checked.Disarmed
+	if checked.Group == 0 {
+		// group of fired value is cancelled
+		v.Group = 0
+	}
+	if (v.Trailing != nil) && (checked.Trailing != nil) {
+		v.Trailing.Extreme = checked.Trailing.Extreme
+		v.Value = checked.Value
+	}
+	if (v.Dynamic != nil) && (checked.Dynamic != nil) && (v.Dynamic.Level == checked.Dynamic.Level) {
+		v.Dynamic.Session = checked.Dynamic.Session
+		v.Dynamic.Fired = checked.Dynamic.Fired
+		v.Value = checked.Value
+	}
+	if (v.Rule != nil) && (checked.Rule != nil) && (v.Rule.Expr == checked.Rule.Expr) {
+		v.Rule.Active = checked.Rule.Active
+	}
+	if (v.Touch != nil) && (checked.Touch != nil) {
+		v.Touch.Count = checked.Touch.Count
+		v.Touch.LastTouch = checked.Touch.LastTouch
+		v.Touch.Near = checked.Touch.Near
+	}
+	if (v.Confirm != nil) && (checked.Confirm != nil) {
+		v.Confirm.LastBar = checked.Confirm.LastBar
+		v.Confirm.LastClose = checked.Confirm.LastClose
+	}
+	if (v.Proximity != nil) && (checked.Proximity != nil) {
+		v.Proximity.Near = checked.Proximity.Near
+	}
+
+	return v
+}
+
+// IsCheckStateChanged return true if check changed level or state of conditions of value since before.
+// Last price isn't compared, it is changed by every check.
+func (v Value) IsCheckStateChanged(before Value) bool {
+	if (v.Value != before.Value) || (v.Disarmed != before.Disarmed) || (v.Group != before.Group) {
+		return true
+	}
+	if (v.Trailing != nil) && (before.Trailing != nil) && (*v.Trailing != *before.Trailing) {
+		return true
+	}
+	if (v.Rule != nil) && (before.Rule != nil) && (*v.Rule != *before.Rule) {
+		return true
+	}
+	if (v.Touch != nil) && (before.Touch != nil) && (*v.Touch != *before.Touch) {
+		return true
+	}
+	if (v.Confirm != nil) && (before.Confirm != nil) && (*v.Confirm != *before.Confirm) {
+		return true
+	}
+	if (v.Proximity != nil) && (before.Proximity != nil) && (*v.Proximity != *before.Proximity) {
+		return true
+	}
+
+	return (v.Dynamic != nil) && (before.Dynamic != nil) && (*v.Dynamic != *before.Dynamic)
+}
+
+// isSame compare values by ID or by level if ID is not set.
+func (v Value) isSame(stored Value) bool {
+	if v.ID != 0 {
+		return v.ID == stored.ID
+	}
+
+	return v.Value == stored.Value
+}
+
+// isDuplicate check that the same condition is already stored.
+func (v Value) isDuplicate(stored Value) bool {
+	if (v.Kind != stored.Kind) || (v.Value != stored.Value) {
+		return false
+	}
+	if (v.Dynamic != nil) || (stored.Dynamic != nil) {
+		return (v.Dynamic != nil) && (stored.Dynamic != nil) && (v.Dynamic.Level == stored.Dynamic.Level) && (v.Type == stored.Type)
+	}
+	if (v.Range != nil) && (stored.Range != nil) {
+		return *v.Range == *stored.Range
+	}
+	if (v.Percent != nil) && (stored.Percent != nil) {
+		return (*v.Percent == *stored.Percent) && (v.Type == stored.Type)
+	}
+	if (v.Rule != nil) && (stored.Rule != nil) {
+		return v.Rule.Expr == stored.Rule.Expr
+	}
+	if (v.Touch != nil) && (stored.Touch != nil) {
+		return (v.Touch.Mode == stored.Touch.Mode) && (v.Touch.Target == stored.Touch.Target)
+	}
+
+	return true
+}
+
 func (v Value) String() string {
+	if (v.Kind == KindPercent) && (v.Percent != nil) {
+		return v.percentString()
+	}
+	if (v.Kind == KindTrailing) && (v.Trailing != nil) {
+		return v.trailingString()
+	}
+	if (v.Kind == KindRange) && (v.Range != nil) {
+		return v.rangeString()
+	}
+	if (v.Kind == KindRule) && (v.Rule != nil) {
+		return v.ruleString()
+	}
+	if (v.Kind == KindTouch) && (v.Touch != nil) {
+		return v.touchString()
+	}
+	if v.Dynamic != nil {
+		return v.dynamicString()
+	}
+
 	return fmt.Sprintf("%s %s %s", v.Key, v.Type, v.StringValue())
 }
 
@@ -83,18 +468,34 @@ func (db *DB) initUser(ID int64) {
 	}
 }
 
+// Add add all values or nothing if database can't be saved.
+// IDs of added values are set in values.
 func (db *DB) Add(ID int64, values []Value) error {
 	db.l.Lock()
 	defer db.l.Unlock()
 	db.initUser(ID)
-	for _, val := range values {
+	backup := db.db[ID]
+	backup.Levels = copyLevels(backup.Levels)
+	db.insert(ID, values)
+	if err := db.save(); err != nil {
+		db.db[ID] = backup
+
+		return err
+	}
+
+	return nil
+}
+
+// insert add values which are not duplicates and set their IDs.
+func (db *DB) insert(ID int64, values []Value) {
+	for i, val := range values {
 		key := strings.ToUpper(val.Key)
 		if db.db[ID].Levels[key] == nil {
 			db.db[ID].Levels[key] = []Value{}
 		}
 		exists := false
 		for _, dbV := range db.db[ID].Levels[key] {
-			if val.Value == dbV.Value {
+			if val.isDuplicate(dbV) {
 				exists = true
 				break
 			}
@@ -102,18 +503,44 @@ func (db *DB) Add(ID int64, values []Value) error {
 		if exists {
 			continue
 		}
-		db.db[ID].Levels[key] = append(db.db[ID].Levels[key], val)
+		val.ID = db.nextID(ID)
+		values[i].ID = val.ID
+		db.db[ID].Levels[key] = append(db.db[ID].Levels[key], val.Clone())
 	}
+}
 
-	return db.save()
+func (db *DB) nextID(ID int64) uint64 {
+	u := db.db[ID]
+	if u.NextID == 0 {
+		u.NextID = 1
+	}
+	valID := u.NextID
+	u.NextID++
+	db.db[ID] = u
+
+	return valID
+}
+
+func copyLevels(levels map[string][]Value) map[string][]Value {
+	c := make(map[string][]Value, len(levels))
+	for k, vals := range levels {
+		c[k] = append([]Value(nil), vals...)
+	}
+
+	return c
 }
 
-func (db *DB) DeleteKey(ID int64, key string) error {
+// DeleteKey delete all values of key and return cancelled values linked with them.
+func (db *DB) DeleteKey(ID int64, key string) ([]Value, error) {
 	db.l.Lock()
 	defer db.l.Unlock()
+	var cancelled []Value
+	for _, v := range db.db[ID].Levels[key] {
+		cancelled = append(cancelled, db.deleteLinked(ID, v)...)
+	}
 	db.deleteKey(ID, key)
 
-	return db.save()
+	return cancelled, db.save()
 }
 
 func (db *DB) deleteKey(ID int64, key string) {
@@ -149,30 +576,120 @@ func (db *DB) deleteValue(ID int64, key string, pos int) {
 	}
 }
 
-func (db *DB) DeleteValue(ID int64, val Value) error {
+// DeleteValue delete value by ID, values without ID are matched by level.
+// Values linked with deleted value are deleted too and returned.
+func (db *DB) DeleteValue(ID int64, val Value) ([]Value, error) {
 	db.l.Lock()
 	defer db.l.Unlock()
 	for i, v := range db.db[ID].Levels[val.Key] {
-		if v.Value == val.Value {
+		if val.isSame(v) {
+			val = v
 			db.deleteValue(ID, val.Key, i)
 			break
 		}
 	}
-	if val.Delta > 0 {
-		for i, v := range db.db[ID].Levels[val.Key] {
-			if v.Delta == val.Delta {
-				db.deleteValue(ID, val.Key, i)
+	if len(db.db[ID].Levels[val.Key]) == 0 {
+		db.deleteKey(ID, val.Key)
+	}
+	cancelled := db.deleteLinked(ID, val)
+
+	return cancelled, db.save()
+}
+
+// Get return value by ID.
+func (db *DB) Get(ID int64, valID uint64) (*Value, error) {
+	db.l.RLock()
+	defer db.l.RUnlock()
+	for _, vals := range db.db[ID].Levels {
+		for _, v := range vals {
+			if v.ID == valID {
+				c := v.Clone()
+				return &c, nil
+			}
+		}
+	}
+
+	return nil, ErrValueNotFound
+}
+
+// Update replace stored values which have the same ID or the same key and level for values without ID.
+func (db *DB) Update(ID int64, values []Value) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	if _, exists := db.db[ID]; !exists {
+		return ErrUserNotFound
+	}
+	for _, val := range values {
+		key := strings.ToUpper(val.Key)
+		for i, v := range db.db[ID].Levels[key] {
+			if val.isSame(v) {
+				db.db[ID].Levels[key][i] = val.Clone()
 				break
 			}
 		}
 	}
-	if len(db.db[ID].Levels[val.Key]) == 0 {
-		db.deleteKey(ID, val.Key)
+
+	return db.save()
+}
+
+// UpdateCheckState save state of check of values by ID, other changes of stored values are kept.
+// Values which are deleted during check are skipped.
+func (db *DB) UpdateCheckState(ID int64, values []Value) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	if _, exists := db.db[ID]; !exists {
+		return ErrUserNotFound
+	}
+	for _, val := range values {
+		key := strings.ToUpper(val.Key)
+		for i, v := range db.db[ID].Levels[key] {
+			if v.ID == val.ID {
+				db.db[ID].Levels[key][i] = v.withCheckState(val)
+				break
+			}
+		}
 	}
 
 	return db.save()
 }
 
+// DeleteExpired delete expired values of all users with values linked to them and return deleted values.
+func (db *DB) DeleteExpired(t time.Time) (map[int64][]Value, error) {
+	db.l.Lock()
+	defer db.l.Unlock()
+	expired := map[int64][]Value{}
+	for ID, u := range db.db {
+		for key, vals := range u.Levels {
+			var keep []Value
+			for _, v := range vals {
+				if v.IsExpired(t) {
+					expired[ID] = append(expired[ID], v)
+					continue
+				}
+				keep = append(keep, v)
+			}
+			if len(keep) == len(vals) {
+				continue
+			}
+			if len(keep) == 0 {
+				delete(u.Levels, key)
+				continue
+			}
+			u.Levels[key] = keep
+		}
+	}
+	for ID, vals := range expired {
+		for _, v := range vals {
+			expired[ID] = append(expired[ID], db.deleteLinked(ID, v)...)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	return expired, db.save()
+}
+
 func (db *DB) List(ID int64) []Value {
 	db.l.RLock()
 	defer db.l.RUnlock()
@@ -184,7 +701,9 @@ func (db *DB) List(ID int64) []Value {
 	}
 	var lst []Value
 	for k := range db.db[ID].Levels {
-		lst = append(lst, db.db[ID].Levels[k]...)
+		for _, v := range db.db[ID].Levels[k] {
+			lst = append(lst, v.Clone())
+		}
 	}
 
 	return lst
@@ -219,7 +738,7 @@ func (db *DB) GetSettings(ID int64) (*UserSettings, error) {
 	if _, exists := db.db[ID]; !exists {
 		return nil, ErrUserNotFound
 	}
-	s := db.db[ID].Settings
+	s := db.db[ID].Settings.clone()
 
 	return &s, nil
 }
@@ -229,7 +748,7 @@ func (db *DB) SetSettings(ID int64, settings UserSettings) error {
 	defer db.l.Unlock()
 	db.initUser(ID)
 	u := db.db[ID]
-	u.Settings = settings
+	u.Settings = settings.clone()
 	db.db[ID] = u
 
 	return db.save()
@@ -256,10 +775,45 @@ func New(dbPath string, create bool) (*DB, error) {
 	if err := json.Unmarshal(b, &db.db); err != nil {
 		return nil, fmt.Errorf("Can't unmarshal database: %q.  %w", dbPath, err)
 	}
+	idsChanged := db.assignIDs()
+	if groupsChanged := db.assignGroups(); idsChanged || groupsChanged {
+		if err := db.save(); err != nil {
+			return nil, fmt.Errorf("Can't save database with IDs: %q. %w", dbPath, err)
+		}
+	}
 
 	return &db, nil
 }
 
+// assignIDs set IDs for values stored before IDs were introduced.
+func (db *DB) assignIDs() bool {
+	changed := false
+	for ID, u := range db.db {
+		keys := make([]string, 0, len(u.Levels))
+		for k := range u.Levels {
+			keys = append(keys, k)
+			for _, v := range u.Levels[k] {
+				if v.ID >= u.NextID {
+					u.NextID = v.ID + 1
+				}
+			}
+		}
+		db.db[ID] = u
+		sort.Strings(keys)
+		for _, k := range keys {
+			for i := range u.Levels[k] {
+				if u.Levels[k][i].ID != 0 {
+					continue
+				}
+				u.Levels[k][i].ID = db.nextID(ID)
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
 func ValueTypeFromString(txt string) (ValueType, error) {
 	txt = strings.TrimSpace(txt)
 	if txt == string(AboveCurrent) {
@@ -268,6 +822,21 @@ func ValueTypeFromString(txt string) (ValueType, error) {
 	if txt == string(BelowCurrent) {
 		return BelowCurrent, nil
 	}
+	if strings.EqualFold(txt, string(CrossAny)) {
+		return CrossAny, nil
+	}
 
 	return "", errors.New("Unsupported type")
 }
+
+// InferValueType return type of value which will be triggered when price reach level from current.
+func InferValueType(current float64, level float64) (ValueType, error) {
+	if level > current {
+		return BelowCurrent, nil
+	}
+	if level < current {
+		return AboveCurrent, nil
+	}
+
+	return "", errors.New("Level equals current price")
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsCrossed(t *testing.T) {
+	type tableData struct {
+		v       Value
+		current float64
+		expect  bool
+	}
+
+	data := []tableData{
+		{v: Value{Value: 1.2, Type: BelowCurrent, LastPrice: 1.19}, current: 1.2, expect: true},
+		{v: Value{Value: 1.2, Type: BelowCurrent, LastPrice: 1.19}, current: 1.25, expect: true},
+		{v: Value{Value: 1.2, Type: BelowCurrent, LastPrice: 1.19}, current: 1.195, expect: false},
+		{v: Value{Value: 1.2, Type: BelowCurrent, LastPrice: 1.21}, current: 1.22, expect: false},
+		{v: Value{Value: 1.2, Type: AboveCurrent, LastPrice: 1.21}, current: 1.15, expect: true},
+		{v: Value{Value: 1.2, Type: AboveCurrent, LastPrice: 1.19}, current: 1.18, expect: false},
+		{v: Value{Value: 1.2, Type: CrossAny, LastPrice: 1.19}, current: 1.21, expect: true},
+		{v: Value{Value: 1.2, Type: CrossAny, LastPrice: 1.21}, current: 1.19, expect: true},
+		{v: Value{Value: 1.2, Type: CrossAny, LastPrice: 1.21}, current: 1.22, expect: false},
+		{v: Value{Value: 1.2, Type: CrossAny}, current: 1.22, expect: false},
+		{v: Value{Value: 1.2, Type: BelowCurrent}, current: 1.22, expect: true},
+	}
+	for i, d := range data {
+		if got := d.v.IsCrossed(d.current); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func newTestDB(t *testing.T) *DB {
+	dir, err := ioutil.TempDir("", "fx_alert")
+	if err != nil {
+		t.Fatalf("Can't create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	dbH, err := New(filepath.Join(dir, "db.json"), true)
+	if err != nil {
+		t.Fatalf("Can't create db: %v", err)
+	}
+
+	return dbH
+}
+
+func TestDeleteExpired(t *testing.T) {
+	dbH := newTestDB(t)
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	vals := []Value{
+		{Key: "EURUSD", Value: 1.2, Type: BelowCurrent},
+		{Key: "EURUSD", Value: 1.1, Type: AboveCurrent, TimeInForce: GoodTillDate, ExpiresAt: now},
+		{Key: "GBPUSD", Value: 1.3, Type: BelowCurrent, TimeInForce: EndOfDay, ExpiresAt: now.Add(time.Hour)},
+	}
+	if err := dbH.Add(1, vals); err != nil {
+		t.Fatalf("Can't add values: %v", err)
+	}
+	expired, err := dbH.DeleteExpired(now)
+	if err != nil {
+		t.Fatalf("Can't delete expired: %v", err)
+	}
+	if (len(expired[1]) != 1) || (expired[1][0].Value != 1.1) {
+		t.Fatalf("Unexpected expired values: %v", expired)
+	}
+	if lst := dbH.List(1); len(lst) != 2 {
+		t.Fatalf("Expect 2 values, got %v", lst)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	v := Value{Value: 1.2, Type: BelowCurrent, Repeat: true, Cooldown: time.Hour, MaxCount: 2, Rearm: 20}
+	if !v.CanFire(now) {
+		t.Fatal("Expect new value can fire")
+	}
+	v.LastFired = now
+	v.Count = 1
+	if v.CanFire(now.Add(30 * time.Minute)) {
+		t.Fatal("Expect value in cooldown")
+	}
+	if !v.CanFire(now.Add(time.Hour)) {
+		t.Fatal("Expect value after cooldown")
+	}
+	v.Disarmed = true
+	if v.CanFire(now.Add(time.Hour)) {
+		t.Fatal("Expect disarmed value can't fire")
+	}
+	if v.IsRearmed(1.1999, 0.0002) {
+		t.Fatal("Expect value is not rearmed near level")
+	}
+	if !v.IsRearmed(1.1998, 0.0002) {
+		t.Fatal("Expect value is rearmed")
+	}
+	v.Count = 2
+	if !v.IsFinished() {
+		t.Fatal("Expect value is finished")
+	}
+}
+
+func TestIDs(t *testing.T) {
+	dbH := newTestDB(t)
+	vals := []Value{
+		{Key: "EURUSD", Value: 1.2, Type: BelowCurrent},
+		{Key: "EURUSD", Value: 1.1, Type: AboveCurrent},
+	}
+	if err := dbH.Add(1, vals); err != nil {
+		t.Fatalf("Can't add values: %v", err)
+	}
+	if (vals[0].ID != 1) || (vals[1].ID != 2) {
+		t.Fatalf("Unexpected IDs: %d, %d", vals[0].ID, vals[1].ID)
+	}
+	v, err := dbH.Get(1, 2)
+	if err != nil {
+		t.Fatalf("Can't get value: %v", err)
+	}
+	v.Value = 1.15
+	if err := dbH.Update(1, []Value{*v}); err != nil {
+		t.Fatalf("Can't update value: %v", err)
+	}
+	if _, err := dbH.DeleteValue(1, Value{ID: 1, Key: "EURUSD"}); err != nil {
+		t.Fatalf("Can't delete value: %v", err)
+	}
+	lst := dbH.List(1)
+	if (len(lst) != 1) || (lst[0].ID != 2) || (lst[0].Value != 1.15) {
+		t.Fatalf("Unexpected values: %v", lst)
+	}
+	if _, err := dbH.Get(1, 1); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("Expect not found, got %v", err)
+	}
+}
+
+func TestUpdateCheckState(t *testing.T) {
+	dbH := newTestDB(t)
+	vals := []Value{
+		{Key: "EURUSD", Value: 1.2, Type: BelowCurrent, Repeat: true},
+		{Key: "EURUSD", Value: 1.1, Type: AboveCurrent, Trailing: &Trailing{Extreme: 1.05}},
+		{Key: "GBPUSD", Value: 1.3, Type: BelowCurrent},
+	}
+	if err := dbH.Add(1, vals); err != nil {
+		t.Fatalf("Can't add values: %v", err)
+	}
+	checked := make([]Value, 0, 3)
+	for _, v := range vals {
+		c, err := dbH.Get(1, v.ID)
+		if err != nil {
+			t.Fatalf("Can't get value: %v", err)
+		}
+		checked = append(checked, *c)
+	}
+	// user changes values during check
+	edited := checked[0].Clone()
+	edited.Value = 1.25
+	edited.Note = "retest"
+	if err := dbH.Update(1, []Value{edited}); err != nil {
+		t.Fatalf("Can't update value: %v", err)
+	}
+	if _, err := dbH.DeleteValue(1, checked[2]); err != nil {
+		t.Fatalf("Can't delete value: %v", err)
+	}
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	checked[0].SetLastPrice(1.21)
+	checked[0].Count = 1
+	checked[0].LastFired = now
+	checked[1].SetLastPrice(1.12)
+	checked[1].Trailing.Extreme = 1.12
+	checked[1].Value = 1.17
+	checked[2].SetLastPrice(1.31)
+	if err := dbH.UpdateCheckState(1, checked); err != nil {
+		t.Fatalf("Can't save check state: %v", err)
+	}
+	if lst := dbH.List(1); len(lst) != 2 {
+		t.Fatalf("Expect deleted value isn't restored, got %v", lst)
+	}
+	lst := make([]Value, 0, 2)
+	for _, v := range vals[:2] {
+		c, err := dbH.Get(1, v.ID)
+		if err != nil {
+			t.Fatalf("Can't get value: %v", err)
+		}
+		lst = append(lst, *c)
+	}
+	if (lst[0].Value != 1.25) || (lst[0].Note != "retest") {
+		t.Fatalf("Expect changes of user are kept, got %v", lst[0])
+	}
+	if (lst[0].LastPrice != 1.21) || !lst[0].Checked || (lst[0].Count != 1) || !lst[0].LastFired.Equal(now) {
+		t.Fatalf("Expect check state is saved, got %+v", lst[0])
+	}
+	if (lst[1].Value != 1.17) || (lst[1].Trailing.Extreme != 1.12) {
+		t.Fatalf("Expect trailing stop is saved, got %+v", lst[1])
+	}
+}
+
+func TestIsCheckStateChanged(t *testing.T) {
+	before := Value{Key: "EURUSD", Value: 1.1, Type: AboveCurrent, Trailing: &Trailing{Extreme: 1.05}, LastPrice: 1.12, Checked: true}
+	type tableData struct {
+		change func(v *Value)
+		expect bool
+	}
+
+	data := []tableData{
+		{change: func(v *Value) { v.SetLastPrice(1.13) }, expect: false},
+		{change: func(v *Value) { v.Trailing.Extreme = 1.13 }, expect: true},
+		{change: func(v *Value) { v.Value = 1.11 }, expect: true},
+		{change: func(v *Value) { v.Disarmed = true }, expect: true},
+		{change: func(v *Value) { v.Note = "note" }, expect: false},
+	}
+	for i, d := range data {
+		v := before.Clone()
+		d.change(&v)
+		if got := v.IsCheckStateChanged(before); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func TestAssignIDs(t *testing.T) {
+	dbH := newTestDB(t)
+	dbH.db = map[int64]UserData{
+		1: {Levels: map[string][]Value{
+			"EURUSD": {{Key: "EURUSD", Value: 1.2}, {ID: 5, Key: "EURUSD", Value: 1.1}},
+		}},
+	}
+	if !dbH.assignIDs() {
+		t.Fatal("Expect IDs are assigned")
+	}
+	lst := dbH.List(1)
+	if lst[0].ID != 6 {
+		t.Fatalf("Unexpected ID: %d", lst[0].ID)
+	}
+}
+
+func TestIsCrossedZero(t *testing.T) {
+	type tableData struct {
+		v       Value
+		current float64
+		expect  bool
+	}
+
+	data := []tableData{
+		// spread was checked at 0, so level 0 is not crossed again
+		{v: Value{Value: 0, Type: CrossAny, Checked: true}, current: 0.01, expect: false},
+		{v: Value{Value: 0.005, Type: BelowCurrent, Checked: true}, current: 0.01, expect: true},
+		{v: Value{Value: -0.005, Type: AboveCurrent, Checked: true}, current: 0.001, expect: false},
+		// never checked value keeps touch behaviour
+		{v: Value{Value: -0.005, Type: AboveCurrent}, current: -0.01, expect: true},
+	}
+	for i, d := range data {
+		if got := d.v.IsCrossed(d.current); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import "time"
+
+// Button is inline button of stored notification, Data is command sent to bot when it is pressed.
+type Button struct {
+	Text string
+	Data string
+}
+
+// Notification is stored notification which is sent later.
+type Notification struct {
+	Text    string
+	Time    time.Time
+	Silent  bool
+	Buttons [][]Button
+}
+
+// AddDigest store notification till end of digest window.
+func (db *DB) AddDigest(ID int64, n Notification) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	db.initUser(ID)
+	u := db.db[ID]
+	backup := u.Digest
+	u.Digest = append(append([]Notification(nil), u.Digest...), n)
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Digest = backup
+		db.db[ID] = u
+
+		return err
+	}
+
+	return nil
+}
+
+// Digest return notifications of digest window from old to new.
+func (db *DB) Digest(ID int64) []Notification {
+	db.l.RLock()
+	defer db.l.RUnlock()
+
+	return append([]Notification(nil), db.db[ID].Digest...)
+}
+
+// RemoveDigest delete the first n notifications of digest window after they are sent.
+func (db *DB) RemoveDigest(ID int64, n int) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	u, exists := db.db[ID]
+	if !exists || (n <= 0) {
+		return nil
+	}
+	if n > len(u.Digest) {
+		n = len(u.Digest)
+	}
+	backup := u.Digest
+	u.Digest = append([]Notification(nil), u.Digest[n:]...)
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Digest = backup
+		db.db[ID] = u
+
+		return err
+	}
+
+	return nil
+}
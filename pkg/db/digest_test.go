@@ -0,0 +1,40 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	dbH := newTestDB(t)
+	now := time.Now().UTC()
+	ns := []Notification{
+		{Text: "first", Time: now, Buttons: [][]Button{{{Text: "Snooze 1h", Data: "/snooze 1 1h"}}}},
+		{Text: "second", Time: now, Silent: true},
+		{Text: "third", Time: now},
+	}
+	for _, n := range ns {
+		if err := dbH.AddDigest(1, n); err != nil {
+			t.Fatalf("Can't add to digest: %v", err)
+		}
+	}
+	if got := dbH.Digest(1); !reflect.DeepEqual(got, ns) {
+		t.Fatalf("Expect: %v, got %v", ns, got)
+	}
+	if err := dbH.RemoveDigest(1, 2); err != nil {
+		t.Fatalf("Can't remove digest: %v", err)
+	}
+	if got := dbH.Digest(1); !reflect.DeepEqual(got, ns[2:]) {
+		t.Fatalf("Expect: %v, got %v", ns[2:], got)
+	}
+	if err := dbH.RemoveDigest(1, 5); err != nil {
+		t.Fatalf("Can't remove digest: %v", err)
+	}
+	if got := dbH.Digest(1); len(got) != 0 {
+		t.Fatalf("Expect empty digest, got %v", got)
+	}
+	if got := dbH.Digest(2); len(got) != 0 {
+		t.Fatalf("Expect empty digest of unknown user, got %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DynamicLevel is named level which is resolved from quotes every session.
+type DynamicLevel string
+
+const (
+	// DynamicPDH is high of the previous day.
+	DynamicPDH DynamicLevel = "pdh"
+	// DynamicPDL is low of the previous day.
+	DynamicPDL DynamicLevel = "pdl"
+	// DynamicDO is open of the current day.
+	DynamicDO DynamicLevel = "do"
+	// DynamicWO is open of the current week.
+	DynamicWO DynamicLevel = "wo"
+)
+
+// Dynamic is state of level which is resolved every session, Value is level of the current session.
+type Dynamic struct {
+	Level DynamicLevel
+	// Session is start of day when level was resolved.
+	Session time.Time
+	// Fired is true if not repeated value was triggered in session, it is rearmed in the next one.
+	Fired bool
+}
+
+// SetSession set level of new session and rearm value. False is returned if session is not changed.
+func (v *Value) SetSession(session time.Time, level float64, price float64) bool {
+	if (v.Dynamic == nil) || v.Dynamic.Session.Equal(session) {
+		return false
+	}
+	v.Dynamic.Session = session
+	v.Dynamic.Fired = false
+	v.Value = level
+	// level is crossed from price of the new session only
+	v.SetLastPrice(price)
+	v.Disarmed = false
+
+	return true
+}
+
+func (v Value) dynamicString() string {
+	return fmt.Sprintf("%s %s %s (%s)", v.Key, v.Type, strings.ToUpper(string(v.Dynamic.Level)), v.StringValue())
+}
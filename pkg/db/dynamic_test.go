@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetSession(t *testing.T) {
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	v := Value{Key: "EURUSD", Value: 1.2, Type: BelowCurrent, Precision: 5, LastPrice: 1.21, Disarmed: true, Dynamic: &Dynamic{Level: DynamicPDH, Session: day, Fired: true}}
+	if v.SetSession(day, 1.22, 1.215) {
+		t.Fatalf("Expect the same session")
+	}
+	if v.CanFire(day) {
+		t.Fatalf("Expect fired value can't fire in the same session")
+	}
+	if !v.SetSession(day.Add(24*time.Hour), 1.22, 1.215) {
+		t.Fatalf("Expect new session")
+	}
+	if (v.Value != 1.22) || (v.LastPrice != 1.215) || v.Disarmed || v.Dynamic.Fired || !v.CanFire(day) {
+		t.Fatalf("Unexpected value: %#v %#v", v, v.Dynamic)
+	}
+	if s := v.String(); s != "EURUSD > PDH (1.22000)" {
+		t.Fatalf("Unexpected string: %q", s)
+	}
+	if !v.isDuplicate(Value{Value: 1.22, Type: BelowCurrent, Dynamic: &Dynamic{Level: DynamicPDH}}) {
+		t.Fatalf("Expect duplicate")
+	}
+	for _, stored := range []Value{{Value: 1.22, Type: BelowCurrent}, {Value: 1.22, Type: BelowCurrent, Dynamic: &Dynamic{Level: DynamicWO}}} {
+		if v.isDuplicate(stored) {
+			t.Fatalf("Unexpected duplicate: %#v", stored)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"sort"
+	"strings"
+)
+
+// AddGroup add values as one group: trigger or deletion of any of them cancels the others.
+func (db *DB) AddGroup(ID int64, values []Value) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	db.initUser(ID)
+	backup := db.db[ID]
+	backup.Levels = copyLevels(backup.Levels)
+	group := db.nextGroup(ID)
+	for i := range values {
+		values[i].Group = group
+	}
+	db.insert(ID, values)
+	// duplicates are not added, so group can be left with one value
+	db.cleanGroups(ID)
+	if err := db.save(); err != nil {
+		db.db[ID] = backup
+
+		return err
+	}
+
+	return nil
+}
+
+// Link put stored values into new group and return its ID. Values are removed from their previous groups.
+func (db *DB) Link(ID int64, valIDs []uint64) (uint64, error) {
+	db.l.Lock()
+	defer db.l.Unlock()
+	if _, exists := db.db[ID]; !exists {
+		return 0, ErrUserNotFound
+	}
+	linked := make(map[uint64]bool, len(valIDs))
+	for _, valID := range valIDs {
+		linked[valID] = true
+	}
+	found := 0
+	for _, vals := range db.db[ID].Levels {
+		for _, v := range vals {
+			if linked[v.ID] {
+				found++
+			}
+		}
+	}
+	if found != len(linked) {
+		return 0, ErrValueNotFound
+	}
+	group := db.nextGroup(ID)
+	for key, vals := range db.db[ID].Levels {
+		for i, v := range vals {
+			if linked[v.ID] {
+				db.db[ID].Levels[key][i].Group = group
+			}
+		}
+	}
+	db.cleanGroups(ID)
+
+	return group, db.save()
+}
+
+// Unlink remove value from its group.
+func (db *DB) Unlink(ID int64, valID uint64) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	for key, vals := range db.db[ID].Levels {
+		for i, v := range vals {
+			if v.ID != valID {
+				continue
+			}
+			db.db[ID].Levels[key][i].Group = 0
+			db.cleanGroups(ID)
+
+			return db.save()
+		}
+	}
+
+	return ErrValueNotFound
+}
+
+// Linked return the other values of the group of value.
+func (db *DB) Linked(ID int64, val Value) []Value {
+	db.l.RLock()
+	defer db.l.RUnlock()
+	if val.Group == 0 {
+		return nil
+	}
+	var linked []Value
+	for _, vals := range db.db[ID].Levels {
+		for _, v := range vals {
+			if (v.Group == val.Group) && (v.ID != val.ID) {
+				linked = append(linked, v.Clone())
+			}
+		}
+	}
+	sort.Slice(linked, func(i, j int) bool { return linked[i].ID < linked[j].ID })
+
+	return linked
+}
+
+// DeleteValues delete values by ID, values linked with them are kept.
+func (db *DB) DeleteValues(ID int64, values []Value) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	if _, exists := db.db[ID]; !exists {
+		return nil
+	}
+	deleted := false
+	for _, val := range values {
+		key := strings.ToUpper(val.Key)
+		for i, v := range db.db[ID].Levels[key] {
+			if val.isSame(v) {
+				db.deleteValue(ID, key, i)
+				deleted = true
+				break
+			}
+		}
+		if len(db.db[ID].Levels[key]) == 0 {
+			db.deleteKey(ID, key)
+		}
+	}
+	if !deleted {
+		return nil
+	}
+	db.cleanGroups(ID)
+
+	return db.save()
+}
+
+func (db *DB) deleteLinked(ID int64, val Value) []Value {
+	if val.Group == 0 {
+		return nil
+	}
+	var cancelled []Value
+	for key, vals := range db.db[ID].Levels {
+		var keep []Value
+		for _, v := range vals {
+			if (v.Group == val.Group) && (v.ID != val.ID) {
+				cancelled = append(cancelled, v.Clone())
+				continue
+			}
+			keep = append(keep, v)
+		}
+		if len(keep) == len(vals) {
+			continue
+		}
+		if len(keep) == 0 {
+			db.deleteKey(ID, key)
+			continue
+		}
+		db.db[ID].Levels[key] = keep
+	}
+	sort.Slice(cancelled, func(i, j int) bool { return cancelled[i].ID < cancelled[j].ID })
+	db.cleanGroups(ID)
+
+	return cancelled
+}
+
+func (db *DB) nextGroup(ID int64) uint64 {
+	db.initUser(ID)
+	u := db.db[ID]
+	if u.NextGroup == 0 {
+		u.NextGroup = 1
+	}
+	group := u.NextGroup
+	u.NextGroup++
+	db.db[ID] = u
+
+	return group
+}
+
+// cleanGroups reset group of values which are left alone in their groups.
+func (db *DB) cleanGroups(ID int64) bool {
+	count := map[uint64]int{}
+	for _, vals := range db.db[ID].Levels {
+		for _, v := range vals {
+			if v.Group != 0 {
+				count[v.Group]++
+			}
+		}
+	}
+	changed := false
+	for key, vals := range db.db[ID].Levels {
+		for i, v := range vals {
+			if (v.Group != 0) && (count[v.Group] < 2) {
+				db.db[ID].Levels[key][i].Group = 0
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// assignGroups link pairs of delta values stored before groups were introduced.
+func (db *DB) assignGroups() bool {
+	changed := false
+	for ID, u := range db.db {
+		keys := make([]string, 0, len(u.Levels))
+		for k, vals := range u.Levels {
+			keys = append(keys, k)
+			for _, v := range vals {
+				if v.Group >= u.NextGroup {
+					u.NextGroup = v.Group + 1
+				}
+			}
+		}
+		db.db[ID] = u
+		sort.Strings(keys)
+		for _, k := range keys {
+			vals := u.Levels[k]
+			for i := range vals {
+				if (vals[i].Delta == 0) || (vals[i].Group != 0) {
+					continue
+				}
+				for j := i + 1; j < len(vals); j++ {
+					if (vals[j].Delta != vals[i].Delta) || (vals[j].Group != 0) {
+						continue
+					}
+					group := db.nextGroup(ID)
+					vals[i].Group = group
+					vals[j].Group = group
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroups(t *testing.T) {
+	dbH := newTestDB(t)
+	vals := []Value{
+		{Key: "EURUSD", Value: 1.2, Type: BelowCurrent},
+		{Key: "EURUSD", Value: 1.1, Type: AboveCurrent},
+	}
+	if err := dbH.AddGroup(1, vals); err != nil {
+		t.Fatalf("Can't add group: %v", err)
+	}
+	if (vals[0].Group == 0) || (vals[0].Group != vals[1].Group) {
+		t.Fatalf("Unexpected groups: %d, %d", vals[0].Group, vals[1].Group)
+	}
+	if err := dbH.Add(1, []Value{{Key: "GBPUSD", Value: 1.4, Type: BelowCurrent}}); err != nil {
+		t.Fatalf("Can't add value: %v", err)
+	}
+	cancelled, err := dbH.DeleteValue(1, Value{ID: 1, Key: "EURUSD"})
+	if err != nil {
+		t.Fatalf("Can't delete value: %v", err)
+	}
+	if (len(cancelled) != 1) || (cancelled[0].ID != 2) {
+		t.Fatalf("Unexpected cancelled values: %v", cancelled)
+	}
+	if lst := dbH.List(1); (len(lst) != 1) || (lst[0].ID != 3) {
+		t.Fatalf("Unexpected values: %v", lst)
+	}
+
+	if err := dbH.Add(1, []Value{{Key: "USDJPY", Value: 110, Type: BelowCurrent}}); err != nil {
+		t.Fatalf("Can't add value: %v", err)
+	}
+	if _, err := dbH.Link(1, []uint64{3, 100}); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("Expect not found, got %v", err)
+	}
+	group, err := dbH.Link(1, []uint64{3, 4})
+	if err != nil {
+		t.Fatalf("Can't link values: %v", err)
+	}
+	if v, _ := dbH.Get(1, 4); v.Group != group {
+		t.Fatalf("Expect group %d, got %d", group, v.Group)
+	}
+	cancelled = dbH.Linked(1, Value{ID: 4, Group: group})
+	if (len(cancelled) != 1) || (cancelled[0].Key != "GBPUSD") {
+		t.Fatalf("Unexpected linked values: %v", cancelled)
+	}
+	if v, _ := dbH.Get(1, 3); v.Group != group {
+		t.Fatalf("Expect linked value is kept till it is cancelled, got group %d", v.Group)
+	}
+	if err := dbH.DeleteValues(1, cancelled); err != nil {
+		t.Fatalf("Can't cancel linked values: %v", err)
+	}
+	if _, err := dbH.Get(1, 3); err == nil {
+		t.Fatalf("Expect cancelled value is deleted")
+	}
+	if v, _ := dbH.Get(1, 4); v.Group != 0 {
+		t.Fatalf("Expect single value is unlinked, got group %d", v.Group)
+	}
+}
+
+func TestUnlink(t *testing.T) {
+	dbH := newTestDB(t)
+	now := time.Now()
+	vals := []Value{
+		{Key: "EURUSD", Value: 1.2, Type: BelowCurrent, TimeInForce: GoodTillDate, ExpiresAt: now.Add(-time.Minute)},
+		{Key: "EURUSD", Value: 1.1, Type: AboveCurrent},
+		{Key: "EURUSD", Value: 1.0, Type: AboveCurrent},
+	}
+	if err := dbH.AddGroup(1, vals); err != nil {
+		t.Fatalf("Can't add group: %v", err)
+	}
+	if err := dbH.Unlink(1, 3); err != nil {
+		t.Fatalf("Can't unlink value: %v", err)
+	}
+	expired, err := dbH.DeleteExpired(now)
+	if err != nil {
+		t.Fatalf("Can't delete expired values: %v", err)
+	}
+	if len(expired[1]) != 2 {
+		t.Fatalf("Expect expired value and linked one, got %v", expired[1])
+	}
+	if lst := dbH.List(1); (len(lst) != 1) || (lst[0].ID != 3) {
+		t.Fatalf("Unexpected values: %v", lst)
+	}
+}
+
+func TestAssignGroups(t *testing.T) {
+	dbH := newTestDB(t)
+	dbH.db = map[int64]UserData{
+		1: {Levels: map[string][]Value{
+			"EURUSD": {
+				{ID: 1, Key: "EURUSD", Value: 1.2, Delta: 50},
+				{ID: 2, Key: "EURUSD", Value: 1.15},
+				{ID: 3, Key: "EURUSD", Value: 1.1, Delta: 50},
+			},
+		}},
+	}
+	if !dbH.assignGroups() {
+		t.Fatal("Expect groups are assigned")
+	}
+	lvls := dbH.db[1].Levels["EURUSD"]
+	if (lvls[0].Group == 0) || (lvls[0].Group != lvls[2].Group) || (lvls[1].Group != 0) {
+		t.Fatalf("Unexpected groups: %v", lvls)
+	}
+	if dbH.assignGroups() {
+		t.Fatal("Expect groups are not changed")
+	}
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// MomentumAll is symbol of momentum rule for all symbols.
+const MomentumAll = "*"
+
+// MomentumWindows is supported lookback windows of momentum.
+var MomentumWindows = []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// MomentumRule is move of symbol in points over window which is sent to user.
+type MomentumRule struct {
+	// Symbol or MomentumAll.
+	Symbol string
+	Window time.Duration
+	Points int64
+}
+
+// Momentum is settings of momentum notifications, default rules are used if no rules are set.
+// Momentum is enabled by default, so users who got it before settings were added keep it.
+type Momentum struct {
+	Disabled bool
+	Rules    []MomentumRule
+}
+
+// IsMomentumWindow return true if window is supported.
+func IsMomentumWindow(w time.Duration) bool {
+	for _, mw := range MomentumWindows {
+		if w == mw {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultMomentumRules return rules of symbol used if user has no own rules.
+func DefaultMomentumRules(symbol string) []MomentumRule {
+	points := int64(50)
+	if strings.EqualFold(symbol, "btcusd") {
+		points = 500
+	}
+
+	return []MomentumRule{{Symbol: strings.ToUpper(symbol), Window: 5 * time.Minute, Points: points}}
+}
+
+// MomentumRules return rules of symbol sorted by window, rule of symbol overrides rule for all symbols with the same window.
+func (s UserSettings) MomentumRules(symbol string) []MomentumRule {
+	if s.Momentum.Disabled {
+		return nil
+	}
+	if len(s.Momentum.Rules) == 0 {
+		return DefaultMomentumRules(symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+	var res []MomentumRule
+	for _, w := range MomentumWindows {
+		var found *MomentumRule
+		for i := range s.Momentum.Rules {
+			r := s.Momentum.Rules[i]
+			if r.Window != w {
+				continue
+			}
+			if r.Symbol == symbol {
+				found = &r
+
+				break
+			}
+			if r.Symbol == MomentumAll {
+				found = &r
+			}
+		}
+		if found != nil {
+			res = append(res, MomentumRule{Symbol: symbol, Window: w, Points: found.Points})
+		}
+	}
+
+	return res
+}
+
+// SetRule add rule or replace points of rule with the same symbol and window.
+func (m *Momentum) SetRule(r MomentumRule) {
+	r.Symbol = strings.ToUpper(r.Symbol)
+	for i := range m.Rules {
+		if (m.Rules[i].Symbol == r.Symbol) && (m.Rules[i].Window == r.Window) {
+			m.Rules[i].Points = r.Points
+
+			return
+		}
+	}
+	m.Rules = append(m.Rules, r)
+}
+
+// RemoveRule delete rule of symbol and window, false is returned if there is no such rule.
+func (m *Momentum) RemoveRule(symbol string, window time.Duration) bool {
+	symbol = strings.ToUpper(symbol)
+	for i := range m.Rules {
+		if (m.Rules[i].Symbol == symbol) && (m.Rules[i].Window == window) {
+			m.Rules = append(m.Rules[:i:i], m.Rules[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMomentumRules(t *testing.T) {
+	s := UserSettings{Momentum: Momentum{Disabled: true}}
+	if rules := s.MomentumRules("EURUSD"); rules != nil {
+		t.Fatalf("Expect no rules if momentum is disabled, got %v", rules)
+	}
+	s = UserSettings{}
+	if rules := s.MomentumRules("btcusd"); (len(rules) != 1) || (rules[0].Points != 500) {
+		t.Fatalf("Expect default rule for user without settings, got %v", rules)
+	}
+	s.Momentum.SetRule(MomentumRule{Symbol: MomentumAll, Window: time.Hour, Points: 100})
+	s.Momentum.SetRule(MomentumRule{Symbol: MomentumAll, Window: 5 * time.Minute, Points: 30})
+	s.Momentum.SetRule(MomentumRule{Symbol: "usdjpy", Window: 5 * time.Minute, Points: 20})
+	s.Momentum.SetRule(MomentumRule{Symbol: "USDJPY", Window: 5 * time.Minute, Points: 40})
+	type tableData struct {
+		symbol string
+		expect []MomentumRule
+	}
+
+	data := []tableData{
+		{
+			symbol: "eurusd",
+			expect: []MomentumRule{
+				{Symbol: "EURUSD", Window: 5 * time.Minute, Points: 30},
+				{Symbol: "EURUSD", Window: time.Hour, Points: 100},
+			},
+		},
+		{
+			symbol: "USDJPY",
+			expect: []MomentumRule{
+				{Symbol: "USDJPY", Window: 5 * time.Minute, Points: 40},
+				{Symbol: "USDJPY", Window: time.Hour, Points: 100},
+			},
+		},
+	}
+	for i, d := range data {
+		if rules := s.MomentumRules(d.symbol); !reflect.DeepEqual(rules, d.expect) {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, rules)
+		}
+	}
+	c := s.clone()
+	if !c.Momentum.RemoveRule("usdjpy", 5*time.Minute) || c.Momentum.RemoveRule("usdjpy", time.Hour) {
+		t.Fatalf("Unexpected result of rule removal: %v", c.Momentum.Rules)
+	}
+	if len(s.Momentum.Rules) != 3 {
+		t.Fatalf("Clone changed settings: %v", s.Momentum.Rules)
+	}
+}
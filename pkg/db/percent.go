@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Reference is price from which percent change is calculated.
+type Reference string
+
+const (
+	// ReferenceSet is price when alert was set.
+	ReferenceSet Reference = "set"
+	// ReferenceOpen is open price of the current day.
+	ReferenceOpen Reference = "open"
+)
+
+// PercentChange is condition of KindPercent value. Value.Type is direction of change.
+type PercentChange struct {
+	Percent   float64
+	Reference Reference
+	// Price is reference price for ReferenceSet.
+	Price float64
+}
+
+// ChangePercent return change of price from reference in percents.
+func ChangePercent(ref float64, price float64) float64 {
+	if ref == 0 {
+		return 0
+	}
+
+	return (price - ref) * 100 / ref
+}
+
+// IsPercentReached return true if change from reference reached percent since the last check.
+func (v Value) IsPercentReached(ref float64, currentV float64) bool {
+	if v.Percent == nil {
+		return false
+	}
+	p := v.Percent.Percent
+	curr := ChangePercent(ref, currentV)
+	last := ChangePercent(ref, v.LastPrice)
+	up := (curr >= p) && (!v.isChecked() || (last < p))
+	down := (curr <= -p) && (!v.isChecked() || (last > -p))
+	switch v.Type {
+	case BelowCurrent:
+		return up
+	case AboveCurrent:
+		return down
+	case CrossAny:
+		return up || down
+	}
+
+	return false
+}
+
+func (v Value) percentString() string {
+	from := string(v.Percent.Reference)
+	if v.Percent.Reference == ReferenceSet {
+		from = strconv.FormatFloat(v.Percent.Price, 'f', int(v.Precision), 64)
+	}
+
+	return fmt.Sprintf(
+		"%s %s %s%% from %s",
+		v.Key,
+		v.Type,
+		strconv.FormatFloat(v.Percent.Percent, 'f', -1, 64),
+		from,
+	)
+}
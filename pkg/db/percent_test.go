@@ -0,0 +1,58 @@
+package db
+
+import "testing"
+
+func TestIsPercentReached(t *testing.T) {
+	type tableData struct {
+		v       Value
+		ref     float64
+		current float64
+		expect  bool
+	}
+
+	pc := &PercentChange{Percent: 1, Reference: ReferenceSet, Price: 100}
+	data := []tableData{
+		{v: Value{Type: BelowCurrent, Percent: pc, LastPrice: 100.5}, ref: 100, current: 101, expect: true},
+		{v: Value{Type: BelowCurrent, Percent: pc, LastPrice: 100.5}, ref: 100, current: 100.9, expect: false},
+		{v: Value{Type: BelowCurrent, Percent: pc, LastPrice: 101.5}, ref: 100, current: 102, expect: false},
+		{v: Value{Type: AboveCurrent, Percent: pc, LastPrice: 100}, ref: 100, current: 98, expect: true},
+		{v: Value{Type: AboveCurrent, Percent: pc, LastPrice: 100}, ref: 100, current: 102, expect: false},
+		{v: Value{Type: CrossAny, Percent: pc, LastPrice: 100}, ref: 100, current: 102, expect: true},
+		{v: Value{Type: CrossAny, Percent: pc, LastPrice: 100}, ref: 100, current: 98.9, expect: true},
+		{v: Value{Type: CrossAny}, ref: 100, current: 98.9, expect: false},
+	}
+	for i, d := range data {
+		if got := d.v.IsPercentReached(d.ref, d.current); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func TestAddPercent(t *testing.T) {
+	dbH := newTestDB(t)
+	pc := PercentChange{Percent: 1, Reference: ReferenceOpen}
+	type tableData struct {
+		v      Value
+		expect bool
+	}
+
+	data := []tableData{
+		{v: Value{Key: "EURUSD", Kind: KindPercent, Type: CrossAny, Percent: &pc}, expect: true},
+		{v: Value{Key: "EURUSD", Kind: KindPercent, Type: CrossAny, Percent: &PercentChange{Percent: 1, Reference: ReferenceOpen}}, expect: false},
+		{v: Value{Key: "EURUSD", Kind: KindPercent, Type: CrossAny, Percent: &PercentChange{Percent: 2, Reference: ReferenceOpen}}, expect: true},
+		{v: Value{Key: "EURUSD", Kind: KindPercent, Type: CrossAny, Percent: &PercentChange{Percent: 1, Reference: ReferenceSet, Price: 1.2}}, expect: true},
+		{v: Value{Key: "EURUSD", Kind: KindPercent, Type: BelowCurrent, Percent: &pc}, expect: true},
+	}
+	for i, d := range data {
+		added := []Value{d.v}
+		if err := dbH.Add(1, added); err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if (added[0].ID != 0) != d.expect {
+			t.Fatalf("Test %d Expect added: %v, got ID %d", i, d.expect, added[0].ID)
+		}
+	}
+	if n := len(dbH.List(1)); n != 4 {
+		t.Fatalf("Expect 4 values, got %d", n)
+	}
+}
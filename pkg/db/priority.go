@@ -0,0 +1,146 @@
+package db
+
+import "time"
+
+// Priority is delivery of notification: low is silent, high is repeated until acknowledged.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// NotificationKind is source of notification with own priority in user settings.
+type NotificationKind string
+
+const (
+	NotificationLevel    NotificationKind = "level"
+	NotificationMomentum NotificationKind = "momentum"
+	NotificationPattern  NotificationKind = "pattern"
+)
+
+// Priority return priority of notification kind, normal is default.
+func (s UserSettings) Priority(kind NotificationKind) Priority {
+	if p, exists := s.Priorities[kind]; exists {
+		return p
+	}
+
+	return PriorityNormal
+}
+
+// AlertPriority return priority of value, priority of level notifications is default.
+func (s UserSettings) AlertPriority(v Value) Priority {
+	if v.Priority != "" {
+		return v.Priority
+	}
+
+	return s.Priority(NotificationLevel)
+}
+
+// Ack is high priority notification which is repeated until it is acknowledged.
+type Ack struct {
+	ID uint64
+	Notification
+	// Sent is time of the last send, zero if notification is waiting for end of quiet hours.
+	Sent    time.Time
+	Repeats int
+}
+
+// AddAck store notification to repeat and return its ID for Ack button.
+func (db *DB) AddAck(ID int64, n Notification, sent time.Time) (uint64, error) {
+	db.l.Lock()
+	defer db.l.Unlock()
+	db.initUser(ID)
+	u := db.db[ID]
+	backup := u
+	u.NextAck++
+	u.Acks = append(append([]Ack(nil), u.Acks...), Ack{ID: u.NextAck, Notification: n, Sent: sent})
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		db.db[ID] = backup
+
+		return 0, err
+	}
+
+	return u.NextAck, nil
+}
+
+// Acks return notifications of user which are not acknowledged.
+func (db *DB) Acks(ID int64) []Ack {
+	db.l.RLock()
+	defer db.l.RUnlock()
+
+	return append([]Ack(nil), db.db[ID].Acks...)
+}
+
+// UpdateAck save send time and repeats of notification, acknowledged notification is not restored.
+func (db *DB) UpdateAck(ID int64, a Ack) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	u, exists := db.db[ID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	for i := range u.Acks {
+		if u.Acks[i].ID != a.ID {
+			continue
+		}
+		backup := u.Acks
+		u.Acks = append([]Ack(nil), u.Acks...)
+		u.Acks[i] = a
+		db.db[ID] = u
+		if err := db.save(); err != nil {
+			u.Acks = backup
+			db.db[ID] = u
+
+			return err
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// Acknowledge stop repeats of notifications, all notifications of user are acknowledged if IDs are empty.
+// Number of acknowledged notifications is returned.
+func (db *DB) Acknowledge(ID int64, ackIDs []uint64) (int, error) {
+	db.l.Lock()
+	defer db.l.Unlock()
+	u, exists := db.db[ID]
+	if !exists || (len(u.Acks) == 0) {
+		return 0, nil
+	}
+	var kept []Ack
+	for _, a := range u.Acks {
+		if len(ackIDs) > 0 && !containsID(ackIDs, a.ID) {
+			kept = append(kept, a)
+		}
+	}
+	n := len(u.Acks) - len(kept)
+	if n == 0 {
+		return 0, nil
+	}
+	backup := u.Acks
+	u.Acks = kept
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Acks = backup
+		db.db[ID] = u
+
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func containsID(ids []uint64, ID uint64) bool {
+	for _, v := range ids {
+		if v == ID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriority(t *testing.T) {
+	s := UserSettings{}
+	if p := s.Priority(NotificationMomentum); p != PriorityNormal {
+		t.Fatalf("Expect default priority, got %q", p)
+	}
+	s.Priorities = map[NotificationKind]Priority{NotificationLevel: PriorityHigh, NotificationPattern: PriorityLow}
+	type tableData struct {
+		v      Value
+		expect Priority
+	}
+
+	data := []tableData{
+		{v: Value{}, expect: PriorityHigh},
+		{v: Value{Priority: PriorityLow}, expect: PriorityLow},
+	}
+	for i, d := range data {
+		if p := s.AlertPriority(d.v); p != d.expect {
+			t.Fatalf("Test %d Expect: %q, got %q", i, d.expect, p)
+		}
+	}
+	c := s.clone()
+	c.Priorities[NotificationLevel] = PriorityLow
+	if s.Priority(NotificationLevel) != PriorityHigh {
+		t.Fatalf("Clone changed settings: %v", s.Priorities)
+	}
+}
+
+func TestAcks(t *testing.T) {
+	dbH := newTestDB(t)
+	now := time.Now()
+	var ids []uint64
+	for _, text := range []string{"first", "second", "third"} {
+		ID, err := dbH.AddAck(1, Notification{Text: text, Time: now}, now)
+		if err != nil {
+			t.Fatalf("Can't add ack: %v", err)
+		}
+		ids = append(ids, ID)
+	}
+	if (ids[0] == 0) || (ids[0] == ids[1]) || (ids[1] == ids[2]) {
+		t.Fatalf("Expect unique IDs, got %v", ids)
+	}
+	a := dbH.Acks(1)[1]
+	a.Repeats = 3
+	if err := dbH.UpdateAck(1, a); err != nil {
+		t.Fatalf("Can't update ack: %v", err)
+	}
+	if got := dbH.Acks(1)[1]; (got.Repeats != 3) || (got.Text != "second") {
+		t.Fatalf("Unexpected ack: %v", got)
+	}
+	if n, err := dbH.Acknowledge(1, []uint64{ids[1], 100}); (err != nil) || (n != 1) {
+		t.Fatalf("Expect 1 acknowledged, got %d. %v", n, err)
+	}
+	// acknowledged notification is not restored by update
+	if err := dbH.UpdateAck(1, a); err != nil {
+		t.Fatalf("Can't update ack: %v", err)
+	}
+	if n := len(dbH.Acks(1)); n != 2 {
+		t.Fatalf("Expect 2 acks, got %d", n)
+	}
+	if n, err := dbH.Acknowledge(1, nil); (err != nil) || (n != 2) {
+		t.Fatalf("Expect 2 acknowledged, got %d. %v", n, err)
+	}
+	if n := len(dbH.Acks(1)); n != 0 {
+		t.Fatalf("Expect no acks, got %d", n)
+	}
+}
@@ -0,0 +1,23 @@
+package db
+
+import "math"
+
+// Proximity is warning before level is reached, it is sent once per approach.
+type Proximity struct {
+	// Distance from level: points, percent of price, ATR or percent of ADR.
+	Distance Offset
+	// Near is true while price is inside of distance, warning is sent on entry only.
+	Near bool
+}
+
+// Approached update proximity state with price and return true if price came within distance of level.
+func (v *Value) Approached(currentV float64, distance float64) bool {
+	if v.Proximity == nil {
+		return false
+	}
+	near := math.Abs(currentV-v.Value) <= distance
+	entered := near && !v.Proximity.Near
+	v.Proximity.Near = near
+
+	return entered
+}
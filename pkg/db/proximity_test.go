@@ -0,0 +1,29 @@
+package db
+
+import "testing"
+
+func TestApproached(t *testing.T) {
+	type tableData struct {
+		price  float64
+		expect bool
+	}
+
+	data := []tableData{
+		{price: 1.1950, expect: false},
+		{price: 1.1975, expect: true},
+		{price: 1.1980, expect: false},
+		{price: 1.1972, expect: false},
+		{price: 1.1960, expect: false},
+		{price: 1.1990, expect: true},
+	}
+	v := Value{Key: "EURUSD", Value: 1.2, Type: BelowCurrent, Proximity: &Proximity{Distance: Offset{Amount: 30, Unit: OffsetPoints}}}
+	for i, d := range data {
+		if got := v.Approached(d.price, 0.0030); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+	v.Proximity = nil
+	if v.Approached(1.1990, 0.0030) {
+		t.Fatalf("Expect no warning without proximity")
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietMode is delivery of notifications during quiet hours.
+type QuietMode string
+
+const (
+	// QuietDigest defers notifications till the end of quiet hours.
+	QuietDigest QuietMode = "digest"
+	// QuietSilent sends notifications without sound.
+	QuietSilent QuietMode = "silent"
+)
+
+// QuietHours is daily window in local time of user, it can wrap over midnight: 22:00-07:00.
+type QuietHours struct {
+	// From and To are minutes since local midnight.
+	From int
+	To   int
+	Mode QuietMode
+}
+
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d %s", q.From/60, q.From%60, q.To/60, q.To%60, q.Mode)
+}
+
+// Location return timezone of user, UTC is default.
+func (s UserSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// IsQuiet return true if t is inside of quiet hours of user.
+func (s UserSettings) IsQuiet(t time.Time) bool {
+	if (s.Quiet == nil) || (s.Quiet.From == s.Quiet.To) {
+		return false
+	}
+	lt := t.In(s.Location())
+	m := lt.Hour()*60 + lt.Minute()
+	if s.Quiet.From < s.Quiet.To {
+		return (m >= s.Quiet.From) && (m < s.Quiet.To)
+	}
+
+	return (m >= s.Quiet.From) || (m < s.Quiet.To)
+}
+
+// Defer store notification to send it after quiet hours.
+func (db *DB) Defer(ID int64, n Notification) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	db.initUser(ID)
+	u := db.db[ID]
+	backup := u.Deferred
+	u.Deferred = append(append([]Notification(nil), u.Deferred...), n)
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Deferred = backup
+		db.db[ID] = u
+
+		return err
+	}
+
+	return nil
+}
+
+// Deferred return notifications deferred during quiet hours from old to new.
+func (db *DB) Deferred(ID int64) []Notification {
+	db.l.RLock()
+	defer db.l.RUnlock()
+
+	return append([]Notification(nil), db.db[ID].Deferred...)
+}
+
+// RemoveDeferred delete the first n deferred notifications after they are sent.
+func (db *DB) RemoveDeferred(ID int64, n int) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	u, exists := db.db[ID]
+	if !exists || (n <= 0) {
+		return nil
+	}
+	if n > len(u.Deferred) {
+		n = len(u.Deferred)
+	}
+	backup := u.Deferred
+	u.Deferred = append([]Notification(nil), u.Deferred[n:]...)
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Deferred = backup
+		db.db[ID] = u
+
+		return err
+	}
+
+	return nil
+}
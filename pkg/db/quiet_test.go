@@ -0,0 +1,65 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsQuiet(t *testing.T) {
+	type tableData struct {
+		tz     string
+		quiet  *QuietHours
+		t      time.Time
+		expect bool
+	}
+
+	night := &QuietHours{From: 22 * 60, To: 7 * 60, Mode: QuietDigest}
+	day := &QuietHours{From: 12 * 60, To: 13*60 + 30, Mode: QuietSilent}
+	data := []tableData{
+		{quiet: nil, t: time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC), expect: false},
+		{quiet: night, t: time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC), expect: true},
+		{quiet: night, t: time.Date(2021, 6, 1, 3, 0, 0, 0, time.UTC), expect: true},
+		{quiet: night, t: time.Date(2021, 6, 1, 7, 0, 0, 0, time.UTC), expect: false},
+		{quiet: night, t: time.Date(2021, 6, 1, 21, 59, 0, 0, time.UTC), expect: false},
+		{quiet: day, t: time.Date(2021, 6, 1, 13, 29, 0, 0, time.UTC), expect: true},
+		{quiet: day, t: time.Date(2021, 6, 1, 11, 0, 0, 0, time.UTC), expect: false},
+		// 03:00 UTC is 05:00 in Berlin in summer
+		{tz: "Europe/Berlin", quiet: night, t: time.Date(2021, 6, 1, 3, 0, 0, 0, time.UTC), expect: true},
+		{tz: "Europe/Berlin", quiet: night, t: time.Date(2021, 6, 1, 5, 30, 0, 0, time.UTC), expect: false},
+		{tz: "Europe/Berlin", quiet: night, t: time.Date(2021, 6, 1, 20, 30, 0, 0, time.UTC), expect: true},
+	}
+	for i, d := range data {
+		s := UserSettings{Timezone: d.tz, Quiet: d.quiet}
+		if got := s.IsQuiet(d.t); got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func TestDeferred(t *testing.T) {
+	dbH := newTestDB(t)
+	now := time.Now()
+	buttons := [][]Button{{{Text: "Snooze 1h", Data: "/snooze 1 1h"}}}
+	for _, text := range []string{"first", "second", "third"} {
+		if err := dbH.Defer(1, Notification{Text: text, Time: now, Buttons: buttons}); err != nil {
+			t.Fatalf("Can't defer: %v", err)
+		}
+	}
+	ds := dbH.Deferred(1)
+	if (len(ds) != 3) || (ds[0].Text != "first") || (ds[2].Text != "third") || !reflect.DeepEqual(ds[1].Buttons, buttons) {
+		t.Fatalf("Unexpected deferred: %v", ds)
+	}
+	if err := dbH.RemoveDeferred(1, 2); err != nil {
+		t.Fatalf("Can't remove deferred: %v", err)
+	}
+	if ds := dbH.Deferred(1); (len(ds) != 1) || (ds[0].Text != "third") {
+		t.Fatalf("Expect not sent notification is kept, got %v", ds)
+	}
+	if err := dbH.RemoveDeferred(1, 5); err != nil {
+		t.Fatalf("Can't remove deferred: %v", err)
+	}
+	if ds := dbH.Deferred(1); len(ds) != 0 {
+		t.Fatalf("Expect no deferred, got %v", ds)
+	}
+}
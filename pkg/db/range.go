@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// RangeMode is event of KindRange value.
+type RangeMode string
+
+const (
+	// RangeExit is triggered when price leaves the band.
+	RangeExit RangeMode = "exit"
+	// RangeEnter is triggered when price returns into the band.
+	RangeEnter RangeMode = "enter"
+)
+
+// Range is condition of KindRange value.
+type Range struct {
+	Low  float64
+	High float64
+	Mode RangeMode
+}
+
+func (r Range) Contains(price float64) bool {
+	return (price >= r.Low) && (price <= r.High)
+}
+
+// Distance return distance from price to the nearest border of range.
+func (r Range) Distance(price float64) float64 {
+	if price < r.Low {
+		return r.Low - price
+	}
+	if price > r.High {
+		return price - r.High
+	}
+	if price-r.Low < r.High-price {
+		return price - r.Low
+	}
+
+	return r.High - price
+}
+
+// RangeEvent return breakout direction (up, down) or enter if range event happened since the last check.
+func (v Value) RangeEvent(currentV float64) string {
+	if (v.Range == nil) || !v.isChecked() {
+		return ""
+	}
+	wasInside := v.Range.Contains(v.LastPrice)
+	isInside := v.Range.Contains(currentV)
+	switch v.Range.Mode {
+	case RangeExit:
+		if !wasInside || isInside {
+			return ""
+		}
+		if currentV > v.Range.High {
+			return "up"
+		}
+
+		return "down"
+	case RangeEnter:
+		if wasInside || !isInside {
+			return ""
+		}
+
+		return "enter"
+	}
+
+	return ""
+}
+
+func (v Value) rangeString() string {
+	return fmt.Sprintf(
+		"%s range %s %s %s",
+		v.Key,
+		strconv.FormatFloat(v.Range.Low, 'f', int(v.Precision), 64),
+		strconv.FormatFloat(v.Range.High, 'f', int(v.Precision), 64),
+		v.Range.Mode,
+	)
+}
@@ -0,0 +1,29 @@
+package db
+
+import "testing"
+
+func TestRangeEvent(t *testing.T) {
+	type tableData struct {
+		mode    RangeMode
+		last    float64
+		current float64
+		expect  string
+	}
+
+	data := []tableData{
+		{mode: RangeExit, last: 1.11, current: 1.115, expect: ""},
+		{mode: RangeExit, last: 1.11, current: 1.121, expect: "up"},
+		{mode: RangeExit, last: 1.11, current: 1.09, expect: "down"},
+		{mode: RangeExit, last: 1.13, current: 1.14, expect: ""},
+		{mode: RangeEnter, last: 1.13, current: 1.119, expect: "enter"},
+		{mode: RangeEnter, last: 1.13, current: 1.09, expect: ""},
+		{mode: RangeEnter, last: 1.11, current: 1.115, expect: ""},
+		{mode: RangeEnter, last: 0, current: 1.115, expect: ""},
+	}
+	for i, d := range data {
+		v := Value{Kind: KindRange, Range: &Range{Low: 1.1, High: 1.12, Mode: d.mode}, LastPrice: d.last}
+		if got := v.RangeEvent(d.current); got != d.expect {
+			t.Fatalf("Test %d Expect: %q, got %q", i, d.expect, got)
+		}
+	}
+}
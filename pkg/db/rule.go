@@ -0,0 +1,23 @@
+package db
+
+// Rule is condition of KindRule value, expression is stored in normalized form.
+type Rule struct {
+	Expr string
+	// Active is result of the last evaluation, rule is triggered when it becomes true.
+	Active bool
+}
+
+// SetRuleResult save result of evaluation and return true if rule became true.
+func (v *Value) SetRuleResult(result bool) bool {
+	if v.Rule == nil {
+		return false
+	}
+	triggered := result && !v.Rule.Active
+	v.Rule.Active = result
+
+	return triggered
+}
+
+func (v Value) ruleString() string {
+	return "rule " + v.Rule.Expr
+}
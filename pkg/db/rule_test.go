@@ -0,0 +1,14 @@
+package db
+
+import "testing"
+
+func TestSetRuleResult(t *testing.T) {
+	v := Value{Kind: KindRule, Rule: &Rule{Expr: "close(EURUSD) > 1.1"}}
+	results := []bool{false, true, true, false, true}
+	expect := []bool{false, true, false, false, true}
+	for i, r := range results {
+		if got := v.SetRuleResult(r); got != expect[i] {
+			t.Fatalf("Test %d Expect: %v, got %v", i, expect[i], got)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// IsSnoozed return true if triggers of value are suppressed at time t.
+func (s UserSettings) IsSnoozed(valID uint64, t time.Time) bool {
+	until, exists := s.Snoozed[valID]
+
+	return exists && t.Before(until)
+}
+
+// IsMuted return true if momentum and patterns of symbol are not sent at time t.
+func (s UserSettings) IsMuted(symbol string, t time.Time) bool {
+	until, exists := s.Muted[strings.ToUpper(symbol)]
+
+	return exists && t.Before(until)
+}
+
+// removeExpired delete finished snoozes and mutes.
+func (s *UserSettings) removeExpired(t time.Time) {
+	for k, until := range s.Snoozed {
+		if !t.Before(until) {
+			delete(s.Snoozed, k)
+		}
+	}
+	for k, until := range s.Muted {
+		if !t.Before(until) {
+			delete(s.Muted, k)
+		}
+	}
+}
+
+// Snooze suppress triggers of stored value till time, zero time cancels snooze.
+func (db *DB) Snooze(ID int64, valID uint64, until time.Time) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	if _, exists := db.db[ID]; !exists {
+		return ErrUserNotFound
+	}
+	if !db.hasValue(ID, valID) {
+		return ErrValueNotFound
+	}
+	u := db.db[ID]
+	s := u.Settings.clone()
+	s.removeExpired(time.Now())
+	if until.IsZero() {
+		delete(s.Snoozed, valID)
+	} else {
+		if s.Snoozed == nil {
+			s.Snoozed = map[uint64]time.Time{}
+		}
+		s.Snoozed[valID] = until
+	}
+
+	return db.setSettings(ID, s)
+}
+
+// Mute suppress momentum and patterns of symbol till time, zero time cancels mute.
+func (db *DB) Mute(ID int64, symbol string, until time.Time) error {
+	db.l.Lock()
+	defer db.l.Unlock()
+	db.initUser(ID)
+	symbol = strings.ToUpper(symbol)
+	s := db.db[ID].Settings.clone()
+	s.removeExpired(time.Now())
+	if until.IsZero() {
+		delete(s.Muted, symbol)
+	} else {
+		if s.Muted == nil {
+			s.Muted = map[string]time.Time{}
+		}
+		s.Muted[symbol] = until
+	}
+
+	return db.setSettings(ID, s)
+}
+
+func (db *DB) hasValue(ID int64, valID uint64) bool {
+	for _, vals := range db.db[ID].Levels {
+		for _, v := range vals {
+			if v.ID == valID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// setSettings save settings of user or restore previous ones if database can't be saved.
+func (db *DB) setSettings(ID int64, s UserSettings) error {
+	u := db.db[ID]
+	backup := u.Settings
+	u.Settings = s
+	db.db[ID] = u
+	if err := db.save(); err != nil {
+		u.Settings = backup
+		db.db[ID] = u
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSnooze(t *testing.T) {
+	dbH := newTestDB(t)
+	if err := dbH.Snooze(1, 1, time.Now().Add(time.Hour)); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expect user not found, got %v", err)
+	}
+	if err := dbH.Add(1, []Value{{Key: "EURUSD", Value: 1.2, Type: BelowCurrent}}); err != nil {
+		t.Fatalf("Can't add value: %v", err)
+	}
+	if err := dbH.Snooze(1, 2, time.Now().Add(time.Hour)); !errors.Is(err, ErrValueNotFound) {
+		t.Fatalf("Expect value not found, got %v", err)
+	}
+	now := time.Now()
+	if err := dbH.Snooze(1, 1, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Can't snooze: %v", err)
+	}
+	if err := dbH.Mute(1, "usdjpy", now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Can't mute: %v", err)
+	}
+	s, err := dbH.GetSettings(1)
+	if err != nil {
+		t.Fatalf("Can't get settings: %v", err)
+	}
+	if !s.IsSnoozed(1, now) || s.IsSnoozed(1, now.Add(2*time.Hour)) || s.IsSnoozed(2, now) {
+		t.Fatalf("Unexpected snoozes: %v", s.Snoozed)
+	}
+	if !s.IsMuted("USDJPY", now) || s.IsMuted("USDJPY", now.Add(25*time.Hour)) || s.IsMuted("EURUSD", now) {
+		t.Fatalf("Unexpected mutes: %v", s.Muted)
+	}
+	s.Muted["EURUSD"] = now.Add(time.Hour)
+	if err := dbH.Snooze(1, 1, time.Time{}); err != nil {
+		t.Fatalf("Can't cancel snooze: %v", err)
+	}
+	s, _ = dbH.GetSettings(1)
+	if s.IsSnoozed(1, now) || s.IsMuted("EURUSD", now) {
+		t.Fatalf("Unexpected settings: %#v", s)
+	}
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TouchMode is event of KindTouch value.
+type TouchMode string
+
+const (
+	// TouchCount is triggered on Target touch of level.
+	TouchCount TouchMode = "touch"
+	// TouchRetest is triggered when price returns to level after breakout.
+	TouchRetest TouchMode = "retest"
+)
+
+// Touch is condition and state of KindTouch value, touch is entering of price into tolerance zone around level.
+type Touch struct {
+	Mode TouchMode
+	// Tolerance is half width of zone around level in points.
+	Tolerance int64
+	Target    uint
+	Count     uint
+	LastTouch time.Time
+	// Near is true while price is inside of zone.
+	Near bool
+	// Above is side of price before breakout.
+	Above  bool
+	Broken bool
+}
+
+// Touched update state of touches with price and return true if value is triggered.
+func (v *Value) Touched(currentV float64, tolerance float64, t time.Time) bool {
+	if v.Touch == nil {
+		return false
+	}
+	tc := v.Touch
+	near := math.Abs(currentV-v.Value) <= tolerance
+	// price jumped over zone between checks
+	jumped := !near && !tc.Near && v.isChecked() && ((v.LastPrice-v.Value)*(currentV-v.Value) < 0)
+	entered := (near && !tc.Near) || jumped
+	tc.Near = near
+	switch tc.Mode {
+	case TouchCount:
+		if !entered {
+			return false
+		}
+		if tc.Count >= tc.Target {
+			// repeated value counts touches again after trigger
+			tc.Count = 0
+		}
+		tc.Count++
+		tc.LastTouch = t
+
+		return tc.Count >= tc.Target
+	case TouchRetest:
+		if !tc.Broken {
+			tc.Broken = (tc.Above && (currentV < v.Value-tolerance)) || (!tc.Above && (currentV > v.Value+tolerance))
+			return false
+		}
+		back := (tc.Above && (currentV > v.Value)) || (!tc.Above && (currentV < v.Value))
+		if !entered && !back {
+			return false
+		}
+		tc.Count++
+		tc.LastTouch = t
+		// broken level becomes support or resistance from the other side for the next retest
+		tc.Broken = false
+		if !back {
+			tc.Above = !tc.Above
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (v Value) touchString() string {
+	s := fmt.Sprintf(
+		"%s %s %s",
+		v.Key,
+		v.Touch.Mode,
+		strconv.FormatFloat(v.Value, 'f', int(v.Precision), 64),
+	)
+	if v.Touch.Mode == TouchCount {
+		s += fmt.Sprintf(" x%d", v.Touch.Target)
+	}
+
+	return s + fmt.Sprintf(" tol %d", v.Touch.Tolerance)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTouched(t *testing.T) {
+	type tableData struct {
+		price  float64
+		expect bool
+		count  uint
+	}
+
+	now := time.Now()
+	touches := []tableData{
+		{price: 1.1990, expect: false, count: 0},
+		{price: 1.1999, expect: false, count: 1},
+		{price: 1.2001, expect: false, count: 1},
+		{price: 1.1980, expect: false, count: 1},
+		{price: 1.2002, expect: true, count: 2},
+		{price: 1.2030, expect: false, count: 2},
+		{price: 1.1970, expect: false, count: 1},
+		{price: 1.2000, expect: true, count: 2},
+	}
+	v := Value{Key: "EURUSD", Value: 1.2, Touch: &Touch{Mode: TouchCount, Target: 2}}
+	for i, d := range touches {
+		got := v.Touched(d.price, 0.0005, now)
+		v.LastPrice = d.price
+		if (got != d.expect) || (v.Touch.Count != d.count) {
+			t.Fatalf("Test %d Expect: %v %d, got %v %d", i, d.expect, d.count, got, v.Touch.Count)
+		}
+	}
+
+	retest := []tableData{
+		{price: 1.1990, expect: false},
+		{price: 1.1999, expect: false},
+		{price: 1.2010, expect: false},
+		{price: 1.2003, expect: true},
+	}
+	v = Value{Key: "EURUSD", Value: 1.2, Touch: &Touch{Mode: TouchRetest}}
+	for i, d := range retest {
+		got := v.Touched(d.price, 0.0005, now)
+		v.LastPrice = d.price
+		if got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+	if !v.Touch.Above || v.Touch.Broken || (v.Touch.Count != 1) {
+		t.Fatalf("Unexpected state after retest: %#v", v.Touch)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Trailing is condition of KindTrailing value.
+// Value.Value is the current stop level, Value.Type is AboveCurrent for long and BelowCurrent for short.
+type Trailing struct {
+	Distance Offset
+	// Extreme is the best price since alert was set: high for long, low for short.
+	Extreme float64
+}
+
+// Trail move stop level after the best price and return true if price retraced to the stop.
+func (v *Value) Trail(currentV float64, distance float64) bool {
+	if v.Trailing == nil {
+		return false
+	}
+	t := v.Trailing
+	switch v.Type {
+	case AboveCurrent:
+		if currentV > t.Extreme {
+			t.Extreme = currentV
+		}
+		v.Value = t.Extreme - distance
+
+		return currentV <= v.Value
+	case BelowCurrent:
+		if (t.Extreme == 0) || (currentV < t.Extreme) {
+			t.Extreme = currentV
+		}
+		v.Value = t.Extreme + distance
+
+		return currentV >= v.Value
+	}
+
+	return false
+}
+
+// TrailingSide return long for stop below price and short for stop above.
+func (v Value) TrailingSide() string {
+	if v.Type == BelowCurrent {
+		return "short"
+	}
+
+	return "long"
+}
+
+func (v Value) trailingString() string {
+	extreme := "high"
+	if v.Type == BelowCurrent {
+		extreme = "low"
+	}
+
+	return fmt.Sprintf(
+		"%s trail %s %s stop %s (%s %s)",
+		v.Key,
+		v.TrailingSide(),
+		v.Trailing.Distance.String(),
+		v.StringValue(),
+		extreme,
+		strconv.FormatFloat(v.Trailing.Extreme, 'f', int(v.Precision), 64),
+	)
+}
@@ -0,0 +1,35 @@
+package db
+
+import "testing"
+
+func TestTrail(t *testing.T) {
+	long := Value{Type: AboveCurrent, Trailing: &Trailing{Extreme: 1.2}}
+	steps := []struct {
+		price  float64
+		stop   float64
+		expect bool
+	}{
+		{price: 1.201, stop: 1.2, expect: false},
+		{price: 1.205, stop: 1.204, expect: false},
+		{price: 1.2045, stop: 1.204, expect: false},
+		{price: 1.2039, stop: 1.204, expect: true},
+	}
+	for i, s := range steps {
+		got := long.Trail(s.price, 0.001)
+		if (got != s.expect) || (round5(long.Value) != s.stop) {
+			t.Fatalf("Long step %d Expect: %v %v, got %v %v", i, s.expect, s.stop, got, long.Value)
+		}
+	}
+
+	short := Value{Type: BelowCurrent, Trailing: &Trailing{Extreme: 1.2}}
+	if short.Trail(1.195, 0.001) || (round5(short.Value) != 1.196) {
+		t.Fatalf("Unexpected short stop: %v", short.Value)
+	}
+	if !short.Trail(1.1961, 0.001) {
+		t.Fatal("Expect short stop is hit")
+	}
+}
+
+func round5(v float64) float64 {
+	return float64(int64(v*100000+0.5)) / 100000
+}
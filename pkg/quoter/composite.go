@@ -0,0 +1,79 @@
+package quoter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Composite operations.
+const (
+	CompositeSpread = "-"
+	CompositeRatio  = "/"
+)
+
+// Composite is instrument built from quotes of two symbols: EURUSD-GBPUSD, AUDUSD/NZDUSD.
+type Composite struct {
+	Left  string
+	Right string
+	Op    string
+}
+
+// ParseComposite return composite if both parts are allowed symbols.
+func ParseComposite(symbol string) (*Composite, bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	for _, op := range []string{CompositeSpread, CompositeRatio} {
+		parts := strings.Split(symbol, op)
+		if len(parts) != 2 {
+			continue
+		}
+		l, r := NormalizeSymbol(parts[0]), NormalizeSymbol(parts[1])
+		if (l == r) || !isAllowed(l) || !isAllowed(r) {
+			continue
+		}
+
+		return &Composite{Left: l, Right: r, Op: op}, true
+	}
+
+	return nil, false
+}
+
+func (c Composite) String() string {
+	return c.Left + c.Op + c.Right
+}
+
+// Precision of spread is the biggest one of symbols, ratio has 5 digits.
+func (c Composite) Precision() uint8 {
+	if c.Op == CompositeRatio {
+		return 5
+	}
+	l, r := GetPrecision(c.Left), GetPrecision(c.Right)
+	if l > r {
+		return l
+	}
+
+	return r
+}
+
+// Quote build quote of composite from quotes of symbols.
+// High and low can't be restored from bars of symbols, so they are taken from open and close.
+func (c Composite) Quote(l Quote, r Quote) (*Quote, error) {
+	q := Quote{Symbol: c.String()}
+	switch c.Op {
+	case CompositeSpread:
+		q.Open = l.Open - r.Open
+		q.Close = l.Close - r.Close
+	case CompositeRatio:
+		if (r.Open == 0) || (r.Close == 0) {
+			return nil, fmt.Errorf("Zero quote of: %q", c.Right)
+		}
+		q.Open = l.Open / r.Open
+		q.Close = l.Close / r.Close
+	default:
+		return nil, fmt.Errorf("Unsupported composite: %q", c.Op)
+	}
+	q.High = math.Max(q.Open, q.Close)
+	q.Low = math.Min(q.Open, q.Close)
+
+	return &q, nil
+}
@@ -0,0 +1,68 @@
+package quoter
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseComposite(t *testing.T) {
+	type tableData struct {
+		symbol string
+		expect string
+	}
+
+	data := []tableData{
+		{symbol: "EURUSD-GBPUSD", expect: "EURUSD-GBPUSD"},
+		{symbol: "audusd/nzdusd", expect: "AUDUSD/NZDUSD"},
+		{symbol: "fiber-cable", expect: "EURUSD-GBPUSD"},
+		{symbol: "EUR/USD", expect: ""},
+		{symbol: "EURUSD-EURUSD", expect: ""},
+		{symbol: "EURUSD-XXXYYY", expect: ""},
+		{symbol: "EURUSD", expect: ""},
+	}
+	for i, d := range data {
+		c, ok := ParseComposite(d.symbol)
+		if d.expect == "" {
+			if ok {
+				t.Fatalf("Test %d Expect not composite, got %s", i, c)
+			}
+			continue
+		}
+		if !ok || (c.String() != d.expect) {
+			t.Fatalf("Test %d Expect: %q, got %v", i, d.expect, c)
+		}
+	}
+	if s := NormalizeSymbol("eur/usd"); s != "EURUSD" {
+		t.Fatalf("Expect symbol is normalized, got %q", s)
+	}
+	if s := NormalizeSymbol("eurusd-cable"); s != "EURUSD-GBPUSD" {
+		t.Fatalf("Expect composite is normalized, got %q", s)
+	}
+}
+
+func TestCompositeQuote(t *testing.T) {
+	l := Quote{Open: 1.2, Close: 1.25}
+	r := Quote{Open: 1.4, Close: 1.3}
+	c := Composite{Left: "EURUSD", Right: "GBPUSD", Op: CompositeSpread}
+	q, err := c.Quote(l, r)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (math.Abs(q.Close+0.05) > 1e-9) || (math.Abs(q.Open+0.2) > 1e-9) || (q.High != q.Close) || (q.Low != q.Open) {
+		t.Fatalf("Unexpected spread: %s", q)
+	}
+	c.Op = CompositeRatio
+	q, err = c.Quote(l, r)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(q.Close-1.25/1.3) > 1e-9 {
+		t.Fatalf("Unexpected ratio: %s", q)
+	}
+	if _, err := c.Quote(l, Quote{}); err == nil {
+		t.Fatal("Expect error for zero quote")
+	}
+	if p := GetPrecision("EURJPY/GBPJPY"); p != 5 {
+		t.Fatalf("Expect precision 5, got %d", p)
+	}
+}
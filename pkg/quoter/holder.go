@@ -2,6 +2,7 @@ package quoter
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math/rand"
 	"strings"
@@ -10,9 +11,10 @@ import (
 )
 
 type workerRes struct {
-	q   Quote
-	err error
-	day int
+	q    Quote
+	err  error
+	day  int
+	date time.Time
 }
 
 type Quotes struct {
@@ -20,20 +22,41 @@ type Quotes struct {
 	Current  Quote
 }
 
+// historyDuration is time of stored prices, it must cover the longest momentum window.
+const historyDuration = 2 * time.Hour
+
+// hourBars is number of stored hourly bars.
+const hourBars = 24
+
+// dayBars is number of closed daily bars which are backfilled.
+const dayBars = 30
+
+// price is close of symbol at time of update.
+type price struct {
+	t     time.Time
+	close float64
+}
+
 type Holder struct {
-	m          sync.RWMutex
-	db         map[string]*Quotes
-	seriesHour map[string]map[int]Quote
-	seriesDay  map[string]map[int]Quote
-	lasUpdate  time.Time
-	prevDay    int
+	m  sync.RWMutex
+	db map[string]*Quotes
+	// seriesHour is hourly bars by start of hour.
+	seriesHour map[string]map[time.Time]Quote
+	// seriesDay is daily bars by start of UTC day.
+	seriesDay map[string]map[time.Time]Quote
+	// history is prices of the last updates from old to new.
+	history     map[string][]price
+	lasUpdate   time.Time
+	prevDay     int
+	backfilling bool
 }
 
 func NewHolder(symbols []string) *Holder {
 	h := Holder{
 		db:         map[string]*Quotes{},
-		seriesHour: map[string]map[int]Quote{},
-		seriesDay:  map[string]map[int]Quote{},
+		seriesHour: map[string]map[time.Time]Quote{},
+		seriesDay:  map[string]map[time.Time]Quote{},
+		history:    map[string][]price{},
 		prevDay:    -1,
 	}
 	for _, symb := range symbols {
@@ -63,31 +86,16 @@ func (h *Holder) Update(ctx context.Context, workers uint) {
 	}
 	t := time.Now()
 	currentDay := CurrentDay(t)
-	symbChSize := len(h.db)
-	if currentDay != h.prevDay {
-		symbChSize *= 2
-	}
-	symbCh := make(chan symbolToFetch, symbChSize)
+	toFetch := h.toFetch(t)
+	symbCh := make(chan symbolToFetch, len(toFetch))
 	quCh := make(chan workerRes)
 	for i := uint(0); i < workers; i++ {
 		go worker(ctx, symbCh, quCh)
 	}
 	sendSymbN := 0
-	for symb := range h.db {
+	for _, f := range toFetch {
 		sendSymbN++
-		symbCh <- symbolToFetch{
-			Symbol: symb,
-			Date:   t,
-		}
-	}
-	if currentDay != h.prevDay {
-		for symb := range h.db {
-			sendSymbN++
-			symbCh <- symbolToFetch{
-				Symbol: symb,
-				Date:   PreviousDay(symb, t),
-			}
-		}
+		symbCh <- f
 	}
 	close(symbCh)
 	recvQuN := 0
@@ -100,9 +108,9 @@ func (h *Holder) Update(ctx context.Context, workers uint) {
 			recvQuN++
 			if wRes.err == nil {
 				if currentDay == wRes.day {
-					h.saveCurrentDayQuotes(wRes.q)
+					h.saveCurrentDayQuotes(wRes.q, time.Now())
 				} else {
-					h.saveDayQuotes(wRes.q, wRes.day)
+					h.saveDayQuotes(wRes.q, wRes.date)
 				}
 				log.Printf("Got quote: %v", wRes.q)
 			} else {
@@ -119,12 +127,35 @@ func (h *Holder) Update(ctx context.Context, workers uint) {
 	}
 }
 
-func (h *Holder) saveCurrentDayQuotes(q Quote) {
+// toFetch return quotes to fetch by update at t: current day, previous day when day is changed,
+// and week open bar if it is missing, so week open is known right after start in the middle of week.
+func (h *Holder) toFetch(t time.Time) []symbolToFetch {
+	var res []symbolToFetch
+	dayChanged := CurrentDay(t) != h.prevDay
+	week := WeekStart(t)
+	for symb := range h.db {
+		res = append(res, symbolToFetch{Symbol: symb, Date: t})
+		prev := PreviousDay(symb, t)
+		if dayChanged {
+			res = append(res, symbolToFetch{Symbol: symb, Date: prev})
+		}
+		if week.Equal(dayStart(t)) || (dayChanged && week.Equal(dayStart(prev))) {
+			continue
+		}
+		if _, exist := h.seriesDay[symb][week]; !exist {
+			res = append(res, symbolToFetch{Symbol: symb, Date: week})
+		}
+	}
+
+	return res
+}
+
+func (h *Holder) saveCurrentDayQuotes(q Quote, t time.Time) {
 	if h.db == nil {
 		h.db = map[string]*Quotes{}
 	}
 	if h.seriesHour == nil {
-		h.seriesHour = map[string]map[int]Quote{}
+		h.seriesHour = map[string]map[time.Time]Quote{}
 	}
 	q.Symbol = strings.ToUpper(q.Symbol)
 	qs := h.db[q.Symbol]
@@ -137,10 +168,9 @@ func (h *Holder) saveCurrentDayQuotes(q Quote) {
 		qs.Previous = qs.Current
 		qs.Current = q
 	}
-	t := time.Now()
-	hour := CurrentHour(t)
+	hour := t.UTC().Truncate(time.Hour)
 	if h.seriesHour[q.Symbol] == nil {
-		h.seriesHour[q.Symbol] = map[int]Quote{}
+		h.seriesHour[q.Symbol] = map[time.Time]Quote{}
 	}
 	qq, exist := h.seriesHour[q.Symbol][hour]
 	if exist {
@@ -160,28 +190,205 @@ func (h *Holder) saveCurrentDayQuotes(q Quote) {
 			Close:  q.Close,
 		}
 	}
-	if h.seriesHour[q.Symbol] == nil {
-		h.seriesHour[q.Symbol] = map[int]Quote{}
-	}
 	h.seriesHour[q.Symbol][hour] = qq
+	for start := range h.seriesHour[q.Symbol] {
+		if hour.Sub(start) >= hourBars*time.Hour {
+			delete(h.seriesHour[q.Symbol], start)
+		}
+	}
 	h.db[q.Symbol] = qs
+	h.savePrice(q.Symbol, q.Close, t)
+}
+
+// savePrice add price to history and drop prices older than historyDuration.
+func (h *Holder) savePrice(symbol string, p float64, t time.Time) {
+	if h.history == nil {
+		h.history = map[string][]price{}
+	}
+	prices := append(h.history[symbol], price{t: t, close: p})
+	i := 0
+	for (i < len(prices)) && (t.Sub(prices[i].t) > historyDuration) {
+		i++
+	}
+	h.history[symbol] = prices[i:]
+}
+
+// GetWindowQuote return bar of prices over window till t.
+// Open is the last price before or at start of window, so history must cover whole window.
+func (h *Holder) GetWindowQuote(symbol string, t time.Time, window time.Duration) (*Quote, error) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	symbol = strings.ToUpper(symbol)
+	start := t.Add(-window)
+	var q *Quote
+	for _, p := range h.history[symbol] {
+		if p.t.After(t) {
+			break
+		}
+		if !p.t.After(start) {
+			q = &Quote{Symbol: symbol, Open: p.close, High: p.close, Low: p.close, Close: p.close}
+			continue
+		}
+		if q == nil {
+			return nil, ErrNoQuote
+		}
+		if p.close > q.High {
+			q.High = p.close
+		}
+		if p.close < q.Low {
+			q.Low = p.close
+		}
+		q.Close = p.close
+	}
+	if q == nil {
+		return nil, ErrNoQuote
+	}
+
+	return q, nil
 }
 
-func (h *Holder) saveDayQuotes(q Quote, day int) {
+// dayStart return start of UTC day of t.
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (h *Holder) saveDayQuotes(q Quote, date time.Time) {
 	q.Symbol = strings.ToUpper(q.Symbol)
 	if h.seriesDay == nil {
-		h.seriesDay = map[string]map[int]Quote{}
+		h.seriesDay = map[string]map[time.Time]Quote{}
 	}
 	if h.seriesDay[q.Symbol] == nil {
-		h.seriesDay[q.Symbol] = map[int]Quote{}
+		h.seriesDay[q.Symbol] = map[time.Time]Quote{}
+	}
+	h.seriesDay[q.Symbol][dayStart(date)] = q
+	for day := range h.seriesDay[q.Symbol] {
+		if date.Sub(day) > 2*dayBars*24*time.Hour {
+			delete(h.seriesDay[q.Symbol], day)
+		}
 	}
-	h.seriesDay[q.Symbol][day] = q
 }
 
-// GetQuote return quote by symbol.
+// closedDays return up to count closed daily bars before day of t from old to new.
+// Bars are contiguous trading days, series stops at the first missing day.
+func (h *Holder) closedDays(symbol string, t time.Time, count int) []Quote {
+	series := h.seriesDay[symbol]
+	qs := make([]Quote, count)
+	day := t
+	for i := count - 1; i >= 0; i-- {
+		day = PreviousDay(symbol, day)
+		q, exist := series[dayStart(day)]
+		if !exist {
+			return qs[i+1:]
+		}
+		qs[i] = q
+	}
+
+	return qs
+}
+
+// missingDays return closed days of the last dayBars which aren't fetched yet, the newest are first.
+func (h *Holder) missingDays(t time.Time) []symbolToFetch {
+	var res []symbolToFetch
+	days := map[string]time.Time{}
+	for i := 0; i < dayBars; i++ {
+		for symb := range h.db {
+			day, exist := days[symb]
+			if !exist {
+				day = t
+			}
+			day = PreviousDay(symb, day)
+			days[symb] = day
+			if _, exist := h.seriesDay[symb][dayStart(day)]; !exist {
+				res = append(res, symbolToFetch{Symbol: symb, Date: day})
+			}
+		}
+	}
+
+	return res
+}
+
+// Backfill fetch missing daily bars, so daily series and ATR don't depend on time of start.
+func (h *Holder) Backfill(ctx context.Context, workers uint) {
+	if workers == 0 {
+		return
+	}
+	h.m.Lock()
+	if h.backfilling {
+		h.m.Unlock()
+		return
+	}
+	h.backfilling = true
+	toFetch := h.missingDays(time.Now())
+	h.m.Unlock()
+	defer func() {
+		h.m.Lock()
+		h.backfilling = false
+		h.m.Unlock()
+	}()
+	if len(toFetch) == 0 {
+		return
+	}
+	log.Printf("[INFO] Backfill %d daily bars", len(toFetch))
+	symbCh := make(chan symbolToFetch, len(toFetch))
+	quCh := make(chan workerRes)
+	for i := uint(0); i < workers; i++ {
+		go worker(ctx, symbCh, quCh)
+	}
+	for _, f := range toFetch {
+		symbCh <- f
+	}
+	close(symbCh)
+	for range toFetch {
+		select {
+		case wRes := <-quCh:
+			if wRes.err != nil {
+				log.Printf("[ERROR] Can't fetch daily bar: %q %s. %v", wRes.q.Symbol, wRes.date.Format("2006-01-02"), wRes.err)
+				continue
+			}
+			h.m.Lock()
+			h.saveDayQuotes(wRes.q, wRes.date)
+			h.m.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetQuote return quote by symbol, quotes of composite are built from quotes of its symbols.
 func (h *Holder) GetQuote(symbol string) (*Quotes, error) {
 	h.m.RLock()
 	defer h.m.RUnlock()
+	if c, ok := ParseComposite(symbol); ok {
+		return h.getCompositeQuote(*c)
+	}
+
+	return h.getQuote(symbol)
+}
+
+func (h *Holder) getCompositeQuote(c Composite) (*Quotes, error) {
+	l, err := h.getQuote(c.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := h.getQuote(c.Right)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := c.Quote(l.Previous, r.Previous)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := c.Quote(l.Current, r.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quotes{Previous: *prev, Current: *cur}, nil
+}
+
+func (h *Holder) getQuote(symbol string) (*Quotes, error) {
 	symbol = strings.ToUpper(symbol)
 	qs, exist := h.db[symbol]
 	if !exist {
@@ -195,13 +402,14 @@ func (h *Holder) GetQuote(symbol string) (*Quotes, error) {
 	return &rq, nil
 }
 
-func (h *Holder) GetQuoteByHour(symbol string, hour int) (*Quote, error) {
+// GetQuoteByHour return hourly bar of hour of t.
+func (h *Holder) GetQuoteByHour(symbol string, t time.Time) (*Quote, error) {
 	h.m.RLock()
 	defer h.m.RUnlock()
 	if _, exist := h.seriesHour[symbol]; !exist {
 		return nil, ErrNoQuote
 	}
-	q, exist := h.seriesHour[symbol][hour]
+	q, exist := h.seriesHour[symbol][t.UTC().Truncate(time.Hour)]
 	if !exist {
 		return nil, ErrNoQuote
 	}
@@ -209,13 +417,14 @@ func (h *Holder) GetQuoteByHour(symbol string, hour int) (*Quote, error) {
 	return &q, nil
 }
 
-func (h *Holder) GetQuoteByDay(symbol string, day int) (*Quote, error) {
+// GetQuoteByDay return daily bar of day of date.
+func (h *Holder) GetQuoteByDay(symbol string, date time.Time) (*Quote, error) {
 	h.m.RLock()
 	defer h.m.RUnlock()
 	if _, exist := h.seriesDay[symbol]; !exist {
 		return nil, ErrNoQuote
 	}
-	q, exist := h.seriesDay[symbol][day]
+	q, exist := h.seriesDay[symbol][dayStart(date)]
 	if !exist {
 		return nil, ErrNoQuote
 	}
@@ -223,6 +432,87 @@ func (h *Holder) GetQuoteByDay(symbol string, day int) (*Quote, error) {
 	return &q, nil
 }
 
+// GetWeekOpen return open of week of t, it is open of Monday bar.
+func (h *Holder) GetWeekOpen(symbol string, t time.Time) (float64, error) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	symbol = strings.ToUpper(symbol)
+	week := WeekStart(t)
+	if week.Equal(dayStart(t)) {
+		qs := h.db[symbol]
+		if qs == nil {
+			return 0, ErrNoQuote
+		}
+
+		return qs.Current.Open, nil
+	}
+	q, exist := h.seriesDay[symbol][week]
+	if !exist {
+		return 0, ErrNoQuote
+	}
+
+	return q.Open, nil
+}
+
+// GetATR return average daily range over period closed days before t.
+// Error is returned if less than period days are fetched, e.g. till backfill is done.
+func (h *Holder) GetATR(symbol string, t time.Time, period int) (float64, error) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	symbol = strings.ToUpper(symbol)
+	if period <= 0 {
+		return 0, ErrNoQuote
+	}
+	qs := h.closedDays(symbol, t, period)
+	if len(qs) < period {
+		return 0, fmt.Errorf("Not enough daily bars of %s: %d of %d: %w", symbol, len(qs), period, ErrNoQuote)
+	}
+	sum := 0.0
+	for _, q := range qs {
+		sum += q.High - q.Low
+	}
+
+	return sum / float64(len(qs)), nil
+}
+
+// GetHourSeries return up to count hourly bars till hour of t from old to new.
+// Bars are contiguous, series stops at the first missing hour.
+func (h *Holder) GetHourSeries(symbol string, t time.Time, count int) ([]Quote, error) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	symbol = strings.ToUpper(symbol)
+	series := h.seriesHour[symbol]
+	var qs []Quote
+	hour := t.UTC().Truncate(time.Hour)
+	for i := 0; (i < count) && (i < hourBars); i++ {
+		q, exist := series[hour.Add(-time.Duration(i)*time.Hour)]
+		if !exist {
+			break
+		}
+		qs = append([]Quote{q}, qs...)
+	}
+	if len(qs) == 0 {
+		return nil, ErrNoQuote
+	}
+
+	return qs, nil
+}
+
+// GetDaySeries return up to count daily bars till day of t from old to new, the last one is the current day.
+// Bars are contiguous, series stops at the first missing day.
+func (h *Holder) GetDaySeries(symbol string, t time.Time, count int) ([]Quote, error) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	symbol = strings.ToUpper(symbol)
+	cur := h.db[symbol]
+	if (count <= 0) || (cur == nil) {
+		return nil, ErrNoQuote
+	}
+	qs := append(h.closedDays(symbol, t, count-1), cur.Current)
+
+	return qs, nil
+}
+
 // GetCurrentQuote return current quote.
 func (h *Holder) GetCurrentQuote(symbol string) (*Quote, error) {
 	qs, err := h.GetQuote(symbol)
@@ -252,8 +542,9 @@ func worker(ctx context.Context, symbCh <-chan symbolToFetch, resultCh chan<- wo
 			}
 			q, err := rbfrx(symb.Symbol, symb.Date)
 			wr := workerRes{
-				err: err,
-				day: symb.Date.YearDay(),
+				err:  err,
+				day:  symb.Date.YearDay(),
+				date: symb.Date,
 			}
 			if err == nil {
 				wr.q = *q
@@ -269,6 +560,9 @@ func worker(ctx context.Context, symbCh <-chan symbolToFetch, resultCh chan<- wo
 }
 
 func GetPrecision(symbol string) uint8 {
+	if c, ok := ParseComposite(symbol); ok {
+		return c.Precision()
+	}
 	symbol = strings.ToUpper(symbol)
 	if strings.Contains(symbol, "JPY") {
 		return 3
@@ -321,6 +615,20 @@ func CurrentDay(t time.Time) int {
 	return t.YearDay()
 }
 
+// WeekStart return start of Monday of week of t in UTC.
+func WeekStart(t time.Time) time.Time {
+	day := dayStart(t)
+
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// EndOfDay return start of the next UTC day.
+func EndOfDay(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
 func PreviousDay(symbol string, date time.Time) time.Time {
 	dayDuration := (60 * time.Minute) * 24
 	t := date.UTC().Add(-dayDuration)
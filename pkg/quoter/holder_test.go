@@ -0,0 +1,261 @@
+package quoter
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetWindowQuote(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	for i, p := range []float64{1.2000, 1.2010, 1.1990, 1.2030, 1.2020} {
+		h.savePrice("EURUSD", p, start.Add(time.Duration(i)*5*time.Minute))
+	}
+	type tableData struct {
+		t      time.Time
+		window time.Duration
+		expect Quote
+		err    error
+	}
+
+	data := []tableData{
+		{t: start.Add(20 * time.Minute), window: 5 * time.Minute, expect: Quote{Open: 1.2030, High: 1.2030, Low: 1.2020, Close: 1.2020}},
+		{t: start.Add(20 * time.Minute), window: 15 * time.Minute, expect: Quote{Open: 1.2010, High: 1.2030, Low: 1.1990, Close: 1.2020}},
+		{t: start.Add(12 * time.Minute), window: 10 * time.Minute, expect: Quote{Open: 1.2000, High: 1.2010, Low: 1.1990, Close: 1.1990}},
+		{t: start.Add(20 * time.Minute), window: time.Hour, err: ErrNoQuote},
+	}
+	for i, d := range data {
+		q, err := h.GetWindowQuote("eurusd", d.t, d.window)
+		if d.err != nil {
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Test %d Expect error %v, got %v", i, d.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		d.expect.Symbol = "EURUSD"
+		if *q != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, *q)
+		}
+	}
+	h.savePrice("EURUSD", 1.2040, start.Add(3*time.Hour))
+	if n := len(h.history["EURUSD"]); n != 1 {
+		t.Fatalf("Expect old prices are dropped, got %d", n)
+	}
+}
+
+func TestDaySeriesNewYear(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	bars := []struct {
+		date  time.Time
+		rng   float64
+		close float64
+	}{
+		{date: time.Date(2021, 12, 29, 0, 0, 0, 0, time.UTC), rng: 0.0200, close: 1.0},
+		{date: time.Date(2021, 12, 30, 0, 0, 0, 0, time.UTC), rng: 0.0100, close: 1.1},
+		{date: time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC), rng: 0.0080, close: 1.2},
+		{date: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), rng: 0.0040, close: 1.3},
+	}
+	for _, b := range bars {
+		h.saveDayQuotes(Quote{Symbol: "EURUSD", High: 1 + b.rng, Low: 1, Close: b.close}, b.date)
+	}
+	now := time.Date(2022, 1, 4, 12, 0, 0, 0, time.UTC)
+	h.saveCurrentDayQuotes(Quote{Symbol: "EURUSD", Close: 1.4}, now)
+	atr, err := h.GetATR("EURUSD", now, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(atr-0.0060) > 1e-9 {
+		t.Fatalf("Expect ATR of the last days 0.0060, got %.5f", atr)
+	}
+	qs, err := h.GetDaySeries("EURUSD", now, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect := []float64{1.1, 1.2, 1.3, 1.4}
+	if len(qs) != len(expect) {
+		t.Fatalf("Expect %d bars, got %v", len(expect), qs)
+	}
+	for i, q := range qs {
+		if q.Close != expect[i] {
+			t.Fatalf("Test %d Expect: %v, got %v", i, expect[i], q.Close)
+		}
+	}
+	// missing day stops series
+	delete(h.seriesDay["EURUSD"], time.Date(2021, 12, 30, 0, 0, 0, 0, time.UTC))
+	qs, err = h.GetDaySeries("EURUSD", now, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (len(qs) != 3) || (qs[0].Close != 1.2) {
+		t.Fatalf("Expect bars after gap only, got %v", qs)
+	}
+}
+
+func TestMissingDays(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	now := time.Date(2021, 6, 9, 12, 0, 0, 0, time.UTC)
+	h.saveDayQuotes(Quote{Symbol: "EURUSD", Close: 1.1}, time.Date(2021, 6, 8, 0, 0, 0, 0, time.UTC))
+	days := h.missingDays(now)
+	if len(days) != dayBars-1 {
+		t.Fatalf("Expect %d missing days, got %d", dayBars-1, len(days))
+	}
+	expect := []time.Time{
+		time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC),
+	}
+	for i, e := range expect {
+		if !dayStart(days[i].Date).Equal(e) {
+			t.Fatalf("Test %d Expect: %v, got %v", i, e, days[i].Date)
+		}
+	}
+}
+
+func TestHourSeries(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	updates := []struct {
+		t     time.Time
+		close float64
+	}{
+		{t: day.Add(9 * time.Hour), close: 1.1},
+		{t: day.Add(10 * time.Hour), close: 1.2},
+		{t: day.Add(10*time.Hour + 30*time.Minute), close: 1.3},
+		{t: day.Add(12 * time.Hour), close: 1.4},
+	}
+	for _, u := range updates {
+		h.saveCurrentDayQuotes(Quote{Symbol: "EURUSD", Close: u.close}, u.t)
+	}
+	type tableData struct {
+		t      time.Time
+		count  int
+		expect []Quote
+		err    error
+	}
+
+	data := []tableData{
+		{t: day.Add(10*time.Hour + 45*time.Minute), count: 3, expect: []Quote{
+			{Symbol: "EURUSD", Open: 1.1, High: 1.1, Low: 1.1, Close: 1.1},
+			{Symbol: "EURUSD", Open: 1.2, High: 1.3, Low: 1.2, Close: 1.3},
+		}},
+		// gap at 11:00 stops series
+		{t: day.Add(12 * time.Hour), count: 3, expect: []Quote{{Symbol: "EURUSD", Open: 1.4, High: 1.4, Low: 1.4, Close: 1.4}}},
+		{t: day.Add(11 * time.Hour), count: 2, err: ErrNoQuote},
+	}
+	for i, d := range data {
+		qs, err := h.GetHourSeries("EURUSD", d.t, d.count)
+		if d.err != nil {
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Test %d Expect error %v, got %v", i, d.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(qs, d.expect) {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, qs)
+		}
+	}
+	// bar of the same hour of the next day isn't merged with the old one
+	h.saveCurrentDayQuotes(Quote{Symbol: "EURUSD", Close: 1.5}, day.Add(34*time.Hour))
+	q, err := h.GetQuoteByHour("EURUSD", day.Add(34*time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expect := (Quote{Symbol: "EURUSD", Open: 1.5, High: 1.5, Low: 1.5, Close: 1.5}); *q != expect {
+		t.Fatalf("Expect: %v, got %v", expect, *q)
+	}
+	if _, err := h.GetQuoteByHour("EURUSD", day.Add(10*time.Hour)); !errors.Is(err, ErrNoQuote) {
+		t.Fatalf("Expect bars older than a day are dropped, got %v", err)
+	}
+}
+
+func TestGetATR(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	now := time.Date(2021, 6, 9, 12, 0, 0, 0, time.UTC)
+	// weekend between bars isn't a gap
+	ranges := map[int]float64{4: 0.0030, 7: 0.0010, 8: 0.0020}
+	for day, rng := range ranges {
+		h.saveDayQuotes(Quote{Symbol: "EURUSD", High: 1 + rng, Low: 1}, time.Date(2021, 6, day, 0, 0, 0, 0, time.UTC))
+	}
+	type tableData struct {
+		period int
+		expect float64
+		err    error
+	}
+
+	data := []tableData{
+		{period: 1, expect: 0.0020},
+		{period: 3, expect: 0.0020},
+		{period: 4, err: ErrNoQuote},
+		{period: 14, err: ErrNoQuote},
+		{period: 0, err: ErrNoQuote},
+	}
+	for i, d := range data {
+		atr, err := h.GetATR("eurusd", now, d.period)
+		if d.err != nil {
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Test %d Expect error %v, got %v", i, d.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if math.Abs(atr-d.expect) > 1e-9 {
+			t.Fatalf("Test %d Expect: %.5f, got %.5f", i, d.expect, atr)
+		}
+	}
+}
+
+func TestWeekOpenMidWeek(t *testing.T) {
+	h := NewHolder([]string{"EURUSD"})
+	monday := time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC)
+	type tableData struct {
+		t      time.Time
+		expect []time.Time
+	}
+
+	wednesday := monday.Add(2*24*time.Hour + 12*time.Hour)
+	data := []tableData{
+		// restart on wednesday fetches monday bar with previous day
+		{t: wednesday, expect: []time.Time{monday.AddDate(0, 0, 2), monday.AddDate(0, 0, 1), monday}},
+		// previous day is week open
+		{t: monday.Add(36 * time.Hour), expect: []time.Time{monday.AddDate(0, 0, 1), monday}},
+		// current day is week open
+		{t: monday.Add(12 * time.Hour), expect: []time.Time{monday, monday.AddDate(0, 0, -3)}},
+	}
+	for i, d := range data {
+		got := h.toFetch(d.t)
+		if len(got) != len(d.expect) {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+		for j, f := range got {
+			if !dayStart(f.Date).Equal(d.expect[j]) {
+				t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+			}
+		}
+	}
+	if _, err := h.GetWeekOpen("EURUSD", wednesday); !errors.Is(err, ErrNoQuote) {
+		t.Fatalf("Expect no week open before monday bar is fetched, got %v", err)
+	}
+	h.saveDayQuotes(Quote{Symbol: "EURUSD", Open: 1.2100, Close: 1.2200}, monday)
+	h.saveCurrentDayQuotes(Quote{Symbol: "EURUSD", Open: 1.2300, Close: 1.2400}, wednesday)
+	h.prevDay = CurrentDay(wednesday)
+	open, err := h.GetWeekOpen("eurusd", wednesday)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if open != 1.2100 {
+		t.Fatalf("Expect week open of monday 1.21000, got %.5f", open)
+	}
+	if got := h.toFetch(wednesday); len(got) != 1 {
+		t.Fatalf("Expect fetched week open isn't fetched again, got %v", got)
+	}
+}
@@ -164,12 +164,20 @@ func rbfrx(symbol string, date time.Time) (*Quote, error) {
 	return &q, nil
 }
 
+// IsValidSymbol check that symbol or both symbols of composite are allowed.
 func IsValidSymbol(symbol string) bool {
+	if _, ok := ParseComposite(symbol); ok {
+		return true
+	}
+
+	return isAllowed(symbol)
+}
+
+func isAllowed(symbol string) bool {
 	symbol = strings.ToUpper(symbol)
 	_, exists := allowedQuotes[symbol]
 
 	return exists
-
 }
 
 func GetAllowedSymbols() []string {
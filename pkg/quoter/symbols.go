@@ -0,0 +1,101 @@
+package quoter
+
+import (
+	"sort"
+	"strings"
+)
+
+// symbolAliases is user-facing names of symbols.
+// Alias of not allowed symbol starts working when symbol is added to allowed list.
+var symbolAliases = map[string]string{
+	"CABLE":   "GBPUSD",
+	"FIBER":   "EURUSD",
+	"AUSSIE":  "AUDUSD",
+	"KIWI":    "NZDUSD",
+	"LOONIE":  "USDCAD",
+	"SWISSY":  "USDCHF",
+	"GOPHER":  "GBPJPY",
+	"BITCOIN": "BTCUSD",
+	"GOLD":    "XAUUSD",
+	"SILVER":  "XAGUSD",
+}
+
+var symbolSeparators = strings.NewReplacer("/", "", "-", "", "_", "", ".", "", " ", "")
+
+// NormalizeSymbol remove separators and resolve aliases: eur/usd -> EURUSD, cable -> GBPUSD.
+// Composite of allowed symbols keeps its operation: eurusd-cable -> EURUSD-GBPUSD.
+func NormalizeSymbol(symbol string) string {
+	if c, ok := ParseComposite(symbol); ok {
+		return c.String()
+	}
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	symbol = symbolSeparators.Replace(symbol)
+	if s, exists := symbolAliases[symbol]; exists {
+		return s
+	}
+
+	return symbol
+}
+
+// SuggestSymbols return allowed symbols close to the given one, the closest first.
+func SuggestSymbols(symbol string, limit int) []string {
+	const maxDistance = 2
+	symbol = NormalizeSymbol(symbol)
+	type suggestion struct {
+		symbol   string
+		distance int
+	}
+	var found []suggestion
+	for _, s := range GetAllowedSymbols() {
+		if d := levenshtein(symbol, s); d <= maxDistance {
+			found = append(found, suggestion{symbol: s, distance: d})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance == found[j].distance {
+			return found[i].symbol < found[j].symbol
+		}
+		return found[i].distance < found[j].distance
+	})
+	var r []string
+	for i := 0; (i < len(found)) && (i < limit); i++ {
+		r = append(r, found[i].symbol)
+	}
+
+	return r
+}
+
+// levenshtein return edit distance where transposition of adjacent letters costs 1 (EURUDS -> EURUSD).
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(minInt(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if (i > 1) && (j > 1) && (ra[i-1] == rb[j-2]) && (ra[i-2] == rb[j-1]) {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
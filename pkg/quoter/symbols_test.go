@@ -0,0 +1,41 @@
+package quoter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSymbol(t *testing.T) {
+	data := map[string]string{
+		"eur/usd": "EURUSD",
+		"EUR-USD": "EURUSD",
+		"eur_usd": "EURUSD",
+		"cable":   "GBPUSD",
+		"Gold":    "XAUUSD",
+		"usd":     "USD",
+	}
+	for in, expect := range data {
+		if got := NormalizeSymbol(in); got != expect {
+			t.Fatalf("%q Expect: %q, got %q", in, expect, got)
+		}
+	}
+}
+
+func TestSuggestSymbols(t *testing.T) {
+	type tableData struct {
+		symbol string
+		expect []string
+	}
+
+	data := []tableData{
+		{symbol: "EURUDS", expect: []string{"EURUSD"}},
+		{symbol: "GBPUSF", expect: []string{"GBPUSD"}},
+		{symbol: "XXXXXX", expect: nil},
+	}
+	for i, d := range data {
+		got := SuggestSymbols(d.symbol, 1)
+		if !reflect.DeepEqual(d.expect, got) {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fx_alert/pkg/quoter"
+)
+
+// Timeframe is bar size of series.
+type Timeframe string
+
+const (
+	TimeframeHour Timeframe = "h1"
+	TimeframeDay  Timeframe = "d1"
+)
+
+const defaultPeriod = 14
+
+// maxHourPeriod is limit of period and bars back of h1, holder keeps 24 hour bars.
+const maxHourPeriod = 23
+
+// maxDayPeriod is limit of period and bars back of d1, holder backfills 30 closed days.
+const maxDayPeriod = 30
+
+// Source is market data for evaluation, it is implemented by quoter.Holder.
+type Source interface {
+	GetHourSeries(symbol string, t time.Time, count int) ([]quoter.Quote, error)
+	GetDaySeries(symbol string, t time.Time, count int) ([]quoter.Quote, error)
+	GetATR(symbol string, t time.Time, period int) (float64, error)
+}
+
+type env struct {
+	src Source
+	t   time.Time
+}
+
+// bars return count bars of timeframe from old to new.
+func (e *env) bars(symbol string, tf Timeframe, count int) ([]quoter.Quote, error) {
+	var qs []quoter.Quote
+	var err error
+	if tf == TimeframeHour {
+		qs, err = e.src.GetHourSeries(symbol, e.t, count)
+	} else {
+		qs, err = e.src.GetDaySeries(symbol, e.t, count)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Can't get %s bars of %s: %w", tf, symbol, err)
+	}
+	if len(qs) < count {
+		return nil, fmt.Errorf("Not enough %s bars of %s: %d of %d", tf, symbol, len(qs), count)
+	}
+
+	return qs, nil
+}
+
+func (e *env) closes(symbol string, tf Timeframe, count int) ([]float64, error) {
+	qs, err := e.bars(symbol, tf, count)
+	if err != nil {
+		return nil, err
+	}
+	closes := make([]float64, 0, len(qs))
+	for _, q := range qs {
+		closes = append(closes, q.Close)
+	}
+
+	return closes, nil
+}
+
+type paramKind int
+
+const (
+	paramSymbol paramKind = iota
+	paramTimeframe
+	// paramShift is number of bars back, 0 is the current bar.
+	paramShift
+	paramPeriod
+	paramNumber
+)
+
+func (k paramKind) String() string {
+	switch k {
+	case paramSymbol:
+		return "symbol"
+	case paramTimeframe:
+		return "timeframe (h1, d1)"
+	case paramShift:
+		return "bars back"
+	case paramPeriod:
+		return "period"
+	}
+
+	return "number"
+}
+
+type function struct {
+	params []paramKind
+	// required is number of required params, the others have defaults.
+	required int
+	usage    string
+	eval     func(e *env, c *callNode) (float64, error)
+}
+
+func priceFunction(name string, price func(q quoter.Quote) float64) function {
+	return function{
+		params:   []paramKind{paramSymbol, paramTimeframe, paramShift},
+		required: 1,
+		usage:    name + "(SYMBOL[, TIMEFRAME[, BARS_BACK]])",
+		eval: func(e *env, c *callNode) (float64, error) {
+			qs, err := e.bars(c.symbol, c.timeframe, c.ints[0]+1)
+			if err != nil {
+				return 0, err
+			}
+
+			return price(qs[len(qs)-1-c.ints[0]]), nil
+		},
+	}
+}
+
+var functions = map[string]function{
+	"close": priceFunction("close", func(q quoter.Quote) float64 { return q.Close }),
+	"open":  priceFunction("open", func(q quoter.Quote) float64 { return q.Open }),
+	"high":  priceFunction("high", func(q quoter.Quote) float64 { return q.High }),
+	"low":   priceFunction("low", func(q quoter.Quote) float64 { return q.Low }),
+	"rsi": {
+		params:   []paramKind{paramSymbol, paramTimeframe, paramPeriod},
+		required: 2,
+		usage:    "rsi(SYMBOL, TIMEFRAME[, PERIOD])",
+		eval: func(e *env, c *callNode) (float64, error) {
+			closes, err := e.closes(c.symbol, c.timeframe, c.ints[0]+1)
+			if err != nil {
+				return 0, err
+			}
+
+			return RSI(closes, c.ints[0]), nil
+		},
+	},
+	"sma": {
+		params:   []paramKind{paramSymbol, paramTimeframe, paramPeriod},
+		required: 3,
+		usage:    "sma(SYMBOL, TIMEFRAME, PERIOD)",
+		eval: func(e *env, c *callNode) (float64, error) {
+			closes, err := e.closes(c.symbol, c.timeframe, c.ints[0])
+			if err != nil {
+				return 0, err
+			}
+			sum := 0.0
+			for _, v := range closes {
+				sum += v
+			}
+
+			return sum / float64(len(closes)), nil
+		},
+	},
+	"atr": {
+		params:   []paramKind{paramSymbol, paramPeriod},
+		required: 1,
+		usage:    "atr(SYMBOL[, PERIOD])",
+		eval: func(e *env, c *callNode) (float64, error) {
+			atr, err := e.src.GetATR(c.symbol, e.t, c.ints[0])
+			if err != nil {
+				return 0, fmt.Errorf("Can't get ATR of %s: %w", c.symbol, err)
+			}
+
+			return atr, nil
+		},
+	},
+	"abs": {
+		params:   []paramKind{paramNumber},
+		required: 1,
+		usage:    "abs(NUMBER)",
+		eval: func(e *env, c *callNode) (float64, error) {
+			v, err := c.nums[0].eval(e)
+			if err != nil {
+				return 0, err
+			}
+
+			return math.Abs(v.num), nil
+		},
+	},
+}
+
+// RSI return relative strength index with Wilder's smoothing, len(closes) must be > period.
+func RSI(closes []float64, period int) float64 {
+	var gain, loss float64
+	for i := 1; i < len(closes); i++ {
+		d := closes[i] - closes[i-1]
+		g, l := math.Max(d, 0), math.Max(-d, 0)
+		if i <= period {
+			gain += g / float64(period)
+			loss += l / float64(period)
+			continue
+		}
+		gain = (gain*float64(period-1) + g) / float64(period)
+		loss = (loss*float64(period-1) + l) / float64(period)
+	}
+	if loss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
+
+// callNode is function call, arguments are resolved by check.
+type callNode struct {
+	name      string
+	pos       int
+	args      []node
+	symbol    string
+	timeframe Timeframe
+	ints      []int
+	nums      []node
+}
+
+func (n *callNode) check(c *checker) (valueType, error) {
+	fn, exists := functions[n.name]
+	if !exists {
+		return typeNumber, fmt.Errorf("Unknown function %q at %d", n.name, n.pos+1)
+	}
+	if (len(n.args) < fn.required) || (len(n.args) > len(fn.params)) {
+		return typeNumber, fmt.Errorf("Invalid arguments of %s, usage: %s", n.name, fn.usage)
+	}
+	n.timeframe = TimeframeDay
+	n.ints = nil
+	n.nums = nil
+	for i, kind := range fn.params {
+		var arg node
+		if i < len(n.args) {
+			arg = n.args[i]
+		}
+		if err := n.resolve(c, kind, arg); err != nil {
+			return typeNumber, fmt.Errorf("%s: %s: %w", fn.usage, kind, err)
+		}
+	}
+
+	return typeNumber, nil
+}
+
+// resolve check argument of kind, nil argument is default.
+func (n *callNode) resolve(c *checker, kind paramKind, arg node) error {
+	switch kind {
+	case paramSymbol, paramTimeframe:
+		ident, ok := arg.(*identNode)
+		if arg == nil {
+			return nil
+		}
+		if !ok {
+			return fmt.Errorf("Unexpected: %s", arg)
+		}
+		if kind == paramTimeframe {
+			tf := Timeframe(ident.name)
+			if (tf != TimeframeHour) && (tf != TimeframeDay) {
+				return fmt.Errorf("Unsupported timeframe: %q", ident.name)
+			}
+			n.timeframe = tf
+			return nil
+		}
+		symbol := quoter.NormalizeSymbol(ident.name)
+		if !quoter.IsValidSymbol(symbol) {
+			return fmt.Errorf("Unsupported symbol: %q", ident.name)
+		}
+		n.symbol = strings.ToUpper(symbol)
+		ident.name = n.symbol
+		c.addSymbol(n.symbol)
+	case paramShift, paramPeriod:
+		v := 0.0
+		if kind == paramPeriod {
+			v = defaultPeriod
+		}
+		if arg != nil {
+			num, ok := arg.(*numberNode)
+			if !ok {
+				return fmt.Errorf("Number is expected: %s", arg)
+			}
+			v = num.v
+		}
+		if (v != math.Trunc(v)) || (v < 0) || ((kind == paramPeriod) && (v < 1)) || (v > 100) {
+			return fmt.Errorf("Invalid value: %s", arg)
+		}
+		if (n.timeframe == TimeframeHour) && (v > maxHourPeriod) {
+			return fmt.Errorf("Must be <= %d for h1: %v", maxHourPeriod, v)
+		}
+		if (n.timeframe == TimeframeDay) && (v > maxDayPeriod) {
+			return fmt.Errorf("Must be <= %d for d1: %v", maxDayPeriod, v)
+		}
+		n.ints = append(n.ints, int(v))
+	case paramNumber:
+		t, err := arg.check(c)
+		if err != nil {
+			return err
+		}
+		if t != typeNumber {
+			return fmt.Errorf("Number is expected: %s", arg)
+		}
+		n.nums = append(n.nums, arg)
+	}
+
+	return nil
+}
+
+func (n *callNode) eval(e *env) (value, error) {
+	fn, exists := functions[n.name]
+	if !exists {
+		return value{}, fmt.Errorf("Unknown function: %q", n.name)
+	}
+	v, err := fn.eval(e, n)
+	if err != nil {
+		return value{}, err
+	}
+
+	return value{num: v}, nil
+}
+
+func (n *callNode) String() string {
+	return n.name + "(" + argsString(n.args) + ")"
+}
@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators is sorted by length, so the longest operator is matched first.
+var operators = []string{"<=", ">=", "==", "!=", "&&", "||", "<", ">", "+", "-", "*", "/", "!"}
+
+// lex split expression to tokens, identifiers are lower cased.
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		case unicode.IsDigit(r) || (r == '.'):
+			start := i
+			for (i < len(runes)) && (unicode.IsDigit(runes[i]) || (runes[i] == '.')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r):
+			start := i
+			for (i < len(runes)) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || (runes[i] == '_')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(string(runes[start:i])), pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(string(runes[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("Unexpected character %q at %d", r, i+1)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+
+	return tokens, nil
+}
@@ -0,0 +1,428 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type valueType int
+
+const (
+	typeNumber valueType = iota
+	typeBool
+)
+
+func (t valueType) String() string {
+	if t == typeBool {
+		return "boolean"
+	}
+
+	return "number"
+}
+
+type value struct {
+	num   float64
+	truth bool
+}
+
+type node interface {
+	// check validate types of node and its children.
+	check(c *checker) (valueType, error)
+	eval(e *env) (value, error)
+	String() string
+}
+
+// checker collects symbols used by expression.
+type checker struct {
+	symbols []string
+}
+
+func (c *checker) addSymbol(symbol string) {
+	for _, s := range c.symbols {
+		if s == symbol {
+			return
+		}
+	}
+	c.symbols = append(c.symbols, symbol)
+}
+
+type numberNode struct {
+	v float64
+}
+
+func (n *numberNode) check(c *checker) (valueType, error) {
+	return typeNumber, nil
+}
+
+func (n *numberNode) eval(e *env) (value, error) {
+	return value{num: n.v}, nil
+}
+
+func (n *numberNode) String() string {
+	return strconv.FormatFloat(n.v, 'f', -1, 64)
+}
+
+// identNode is symbol or timeframe, it is allowed as function argument only.
+type identNode struct {
+	name string
+	pos  int
+}
+
+func (n *identNode) check(c *checker) (valueType, error) {
+	return typeNumber, fmt.Errorf("Unexpected identifier %q at %d", n.name, n.pos+1)
+}
+
+func (n *identNode) eval(e *env) (value, error) {
+	return value{}, fmt.Errorf("Can't evaluate identifier: %q", n.name)
+}
+
+func (n *identNode) String() string {
+	return n.name
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) check(c *checker) (valueType, error) {
+	t, err := n.x.check(c)
+	if err != nil {
+		return t, err
+	}
+	expect := typeNumber
+	if n.op == "!" {
+		expect = typeBool
+	}
+	if t != expect {
+		return t, fmt.Errorf("Operator %q expects %s, got %s: %s", opString(n.op), expect, t, n.x)
+	}
+
+	return t, nil
+}
+
+func (n *unaryNode) eval(e *env) (value, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return v, err
+	}
+	if n.op == "!" {
+		return value{truth: !v.truth}, nil
+	}
+
+	return value{num: -v.num}, nil
+}
+
+func (n *unaryNode) String() string {
+	if n.op == "!" {
+		return "not " + wrap(n.x, precedence("!"), false)
+	}
+
+	return "-" + wrap(n.x, precedence("!"), false)
+}
+
+type binaryNode struct {
+	op string
+	l  node
+	r  node
+}
+
+func (n *binaryNode) check(c *checker) (valueType, error) {
+	lt, err := n.l.check(c)
+	if err != nil {
+		return lt, err
+	}
+	rt, err := n.r.check(c)
+	if err != nil {
+		return rt, err
+	}
+	expect, result := typeNumber, typeNumber
+	switch n.op {
+	case "&&", "||":
+		expect, result = typeBool, typeBool
+	case "<", "<=", ">", ">=", "==", "!=":
+		result = typeBool
+	}
+	if (lt != expect) || (rt != expect) {
+		return result, fmt.Errorf("Operator %q expects %s operands: %s", opString(n.op), expect, n)
+	}
+
+	return result, nil
+}
+
+func (n *binaryNode) eval(e *env) (value, error) {
+	l, err := n.l.eval(e)
+	if err != nil {
+		return l, err
+	}
+	// logical operators are short-circuit
+	if (n.op == "&&") && !l.truth {
+		return value{truth: false}, nil
+	}
+	if (n.op == "||") && l.truth {
+		return value{truth: true}, nil
+	}
+	r, err := n.r.eval(e)
+	if err != nil {
+		return r, err
+	}
+	switch n.op {
+	case "&&", "||":
+		return value{truth: r.truth}, nil
+	case "<":
+		return value{truth: l.num < r.num}, nil
+	case "<=":
+		return value{truth: l.num <= r.num}, nil
+	case ">":
+		return value{truth: l.num > r.num}, nil
+	case ">=":
+		return value{truth: l.num >= r.num}, nil
+	case "==":
+		return value{truth: l.num == r.num}, nil
+	case "!=":
+		return value{truth: l.num != r.num}, nil
+	case "+":
+		return value{num: l.num + r.num}, nil
+	case "-":
+		return value{num: l.num - r.num}, nil
+	case "*":
+		return value{num: l.num * r.num}, nil
+	case "/":
+		if r.num == 0 {
+			return value{}, fmt.Errorf("Division by zero: %s", n)
+		}
+
+		return value{num: l.num / r.num}, nil
+	}
+
+	return value{}, fmt.Errorf("Unsupported operator: %q", n.op)
+}
+
+func (n *binaryNode) String() string {
+	p := precedence(n.op)
+
+	return wrap(n.l, p, false) + " " + opString(n.op) + " " + wrap(n.r, p, true)
+}
+
+// wrap put node in parentheses if its operator binds weaker than parent one.
+func wrap(n node, parent int, right bool) string {
+	p := 100
+	switch x := n.(type) {
+	case *binaryNode:
+		p = precedence(x.op)
+	case *unaryNode:
+		p = precedence("!")
+	}
+	if (p < parent) || (right && (p == parent)) {
+		return "(" + n.String() + ")"
+	}
+
+	return n.String()
+}
+
+func precedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "<", "<=", ">", ">=", "==", "!=":
+		return 3
+	case "+", "-":
+		return 4
+	case "*", "/":
+		return 5
+	}
+
+	return 6
+}
+
+func opString(op string) string {
+	switch op {
+	case "&&":
+		return "and"
+	case "||":
+		return "or"
+	case "!":
+		return "not"
+	}
+
+	return op
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+// operator return operator of current token if it is one of ops, keywords and, or, not are operators too.
+func (p *parser) operator(ops ...string) (string, bool) {
+	tok := p.peek()
+	op := ""
+	switch {
+	case tok.kind == tokenOperator:
+		op = tok.text
+	case (tok.kind == tokenIdent) && (tok.text == "and"):
+		op = "&&"
+	case (tok.kind == tokenIdent) && (tok.text == "or"):
+		op = "||"
+	case (tok.kind == tokenIdent) && (tok.text == "not"):
+		op = "!"
+	}
+	for _, o := range ops {
+		if op == o {
+			p.next()
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, unexpected(tok)
+	}
+
+	return n, nil
+}
+
+// levels is binary operators by precedence.
+var levels = map[int][]string{
+	1: {"||"},
+	2: {"&&"},
+	3: {"<", "<=", ">", ">=", "==", "!="},
+	4: {"+", "-"},
+	5: {"*", "/"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level > len(levels) {
+		return p.parseUnary()
+	}
+	l, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.operator(levels[level]...)
+		if !ok {
+			return l, nil
+		}
+		r, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+		// comparisons are not chained: a < b < c
+		if level == 3 {
+			return l, nil
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.operator("!", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if n, isNumber := x.(*numberNode); isNumber && (op == "-") {
+			return &numberNode{v: -n.v}, nil
+		}
+
+		return &unaryNode{op: op, x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %q at %d", tok.text, tok.pos+1)
+		}
+
+		return &numberNode{v: v}, nil
+	case tokenLParen:
+		n, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, unexpected(tok)
+		}
+
+		return n, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return &identNode{name: tok.text, pos: tok.pos}, nil
+		}
+		p.next()
+
+		return p.parseCall(tok)
+	}
+
+	return nil, unexpected(tok)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	c := &callNode{name: name.text, pos: name.pos}
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return c, nil
+	}
+	for {
+		arg, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			return c, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, unexpected(tok)
+		}
+	}
+}
+
+func unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("Unexpected end of expression")
+	}
+
+	return fmt.Errorf("Unexpected %q at %d", tok.text, tok.pos+1)
+}
+
+// argsString join arguments of function call.
+func argsString(args []node) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, a.String())
+	}
+
+	return strings.Join(parts, ", ")
+}
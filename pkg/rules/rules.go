@@ -0,0 +1,70 @@
+// Package rules is expression language for custom alerts:
+// close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY, h1) < 30.
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rule is parsed and checked boolean expression.
+type Rule struct {
+	root    node
+	symbols []string
+}
+
+// Parse parse expression and check types of it.
+func Parse(expr string) (*Rule, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("Empty expression")
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	c := checker{}
+	t, err := root.check(&c)
+	if err != nil {
+		return nil, err
+	}
+	if t != typeBool {
+		return nil, fmt.Errorf("Expression must be condition, got %s: %s", t, root)
+	}
+
+	return &Rule{root: root, symbols: c.symbols}, nil
+}
+
+// String return normalized expression.
+func (r Rule) String() string {
+	return r.root.String()
+}
+
+// Symbols return symbols of expression in order of appearance.
+func (r Rule) Symbols() []string {
+	return append([]string(nil), r.symbols...)
+}
+
+// Eval evaluate expression with market data at time t.
+func (r Rule) Eval(src Source, t time.Time) (bool, error) {
+	v, err := r.root.eval(&env{src: src, t: t})
+	if err != nil {
+		return false, err
+	}
+
+	return v.truth, nil
+}
+
+// Usage return supported functions.
+func Usage() []string {
+	return []string{
+		"close(SYMBOL[, TIMEFRAME[, BARS_BACK]]), open, high, low",
+		"rsi(SYMBOL, TIMEFRAME[, PERIOD]), sma(SYMBOL, TIMEFRAME, PERIOD), atr(SYMBOL[, PERIOD]), abs(NUMBER)",
+		"Operators: + - * / < <= > >= == != and or not. Timeframes: h1 (PERIOD and BARS_BACK <= 23), d1",
+	}
+}
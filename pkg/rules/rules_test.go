@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fx_alert/pkg/quoter"
+)
+
+type testSource struct {
+	hours map[string][]quoter.Quote
+	days  map[string][]quoter.Quote
+}
+
+func lastQuotes(qs []quoter.Quote, count int) ([]quoter.Quote, error) {
+	if len(qs) == 0 {
+		return nil, quoter.ErrNoQuote
+	}
+	if len(qs) > count {
+		qs = qs[len(qs)-count:]
+	}
+
+	return qs, nil
+}
+
+func (s testSource) GetHourSeries(symbol string, t time.Time, count int) ([]quoter.Quote, error) {
+	return lastQuotes(s.hours[symbol], count)
+}
+
+func (s testSource) GetDaySeries(symbol string, t time.Time, count int) ([]quoter.Quote, error) {
+	return lastQuotes(s.days[symbol], count)
+}
+
+func (s testSource) GetATR(symbol string, t time.Time, period int) (float64, error) {
+	return 0.01, nil
+}
+
+func closeQuotes(closes ...float64) []quoter.Quote {
+	qs := make([]quoter.Quote, 0, len(closes))
+	for _, c := range closes {
+		qs = append(qs, quoter.Quote{Open: c, High: c, Low: c, Close: c})
+	}
+
+	return qs
+}
+
+func TestParse(t *testing.T) {
+	type tableData struct {
+		expr   string
+		expect string
+		err    bool
+	}
+
+	data := []tableData{
+		{expr: "close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY, h1) < 30", expect: "close(EURUSD) - close(GBPUSD) > 0.2 and rsi(EURJPY, h1) < 30"},
+		{expr: "CLOSE(eurusd)>1.1 || not (close(cable) < 1.3)", expect: "close(EURUSD) > 1.1 or not (close(GBPUSD) < 1.3)"},
+		{expr: "abs(close(EURUSD, h1, 1) - close(EURUSD)) >= atr(EURUSD) / 2", expect: "abs(close(EURUSD, h1, 1) - close(EURUSD)) >= atr(EURUSD) / 2"},
+		{expr: "close(EURUSD) - (close(GBPUSD) - 1) > 0", expect: "close(EURUSD) - (close(GBPUSD) - 1) > 0"},
+		{expr: "close(EURUSD)", err: true},
+		{expr: "close(EURUSD) > 1 and 2", err: true},
+		{expr: "close(EURUSD) > 1 > 0", err: true},
+		{expr: "close(XXXYYY) > 1", err: true},
+		{expr: "rsi(EURUSD) < 30", err: true},
+		{expr: "rsi(EURUSD, m5) < 30", err: true},
+		{expr: "rsi(EURUSD, h1, 1.5) < 30", err: true},
+		{expr: "rsi(EURUSD, h1, 23) < 30", expect: "rsi(EURUSD, h1, 23) < 30"},
+		{expr: "rsi(EURUSD, h1, 30) < 30", err: true},
+		{expr: "sma(EURUSD, h1, 50) > 1", err: true},
+		{expr: "close(EURUSD, h1, 24) > 1", err: true},
+		{expr: "sma(EURUSD, d1, 30) > 1", expect: "sma(EURUSD, d1, 30) > 1"},
+		{expr: "sma(EURUSD, d1, 50) > 1", err: true},
+		{expr: "atr(EURUSD, 31) > 1", err: true},
+		{expr: "foo(EURUSD) < 30", err: true},
+		{expr: "EURUSD < 30", err: true},
+		{expr: "close(EURUSD) < ", err: true},
+		{expr: "close(EURUSD) < 1 $", err: true},
+		{expr: "", err: true},
+	}
+	for i, d := range data {
+		r, err := Parse(d.expr)
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %s", i, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if r.String() != d.expect {
+			t.Fatalf("Test %d Expect: %q, got %q", i, d.expect, r.String())
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	src := testSource{
+		days: map[string][]quoter.Quote{
+			"EURUSD": closeQuotes(1.1, 1.2),
+			"GBPUSD": closeQuotes(1.3, 1.35),
+		},
+		hours: map[string][]quoter.Quote{
+			"EURJPY": closeQuotes(130, 129, 128, 127, 126, 125, 124, 123, 122, 121, 120, 119, 118, 117, 116),
+		},
+	}
+	type tableData struct {
+		expr   string
+		expect bool
+		err    bool
+	}
+
+	data := []tableData{
+		{expr: "close(GBPUSD) - close(EURUSD) > 0.1 and rsi(EURJPY, h1) < 30", expect: true},
+		{expr: "close(GBPUSD) - close(EURUSD) > 0.2 and rsi(EURJPY, h1) < 30", expect: false},
+		{expr: "close(EURUSD, d1, 1) == 1.1", expect: true},
+		{expr: "sma(EURUSD, d1, 2) > 1.14", expect: true},
+		{expr: "close(EURUSD) > 1 or close(EURUSD, d1, 5) > 1", expect: true},
+		{expr: "close(EURUSD, d1, 5) > 1", err: true},
+		{expr: "close(EURUSD) / (close(GBPUSD) - 1.35) > 1", err: true},
+		{expr: "rsi(EURJPY, d1) < 30", err: true},
+	}
+	for i, d := range data {
+		r, err := Parse(d.expr)
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		got, err := r.Eval(src, time.Now())
+		if d.err {
+			if err == nil {
+				t.Fatalf("Test %d Expect error, got %v", i, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d Unexpected error: %v", i, err)
+		}
+		if got != d.expect {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func TestRSI(t *testing.T) {
+	type tableData struct {
+		closes []float64
+		period int
+		expect float64
+	}
+
+	data := []tableData{
+		{closes: []float64{1, 2, 3, 4}, period: 3, expect: 100},
+		{closes: []float64{4, 3, 2, 1}, period: 3, expect: 0},
+		{closes: []float64{1, 2, 1}, period: 2, expect: 50},
+		{closes: []float64{1, 2, 1, 2, 1}, period: 2, expect: 37.5},
+	}
+	for i, d := range data {
+		if got := RSI(d.closes, d.period); math.Abs(got-d.expect) > 1e-9 {
+			t.Fatalf("Test %d Expect: %v, got %v", i, d.expect, got)
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	r, err := Parse("close(EURUSD) - close(gbpusd) > 0.2 and rsi(EURUSD, h1) < 30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := r.Symbols(); (len(s) != 2) || (s[0] != "EURUSD") || (s[1] != "GBPUSD") {
+		t.Fatalf("Unexpected symbols: %v", s)
+	}
+}
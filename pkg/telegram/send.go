@@ -11,6 +11,10 @@ import (
 type Answer struct {
 	Text          string
 	ReplyKeyboard *ReplyKeyboardMarkup
+	// InlineKeyboard is attached to message instead of ReplyKeyboard.
+	InlineKeyboard *InlineKeyboardMarkup
+	// DisableNotification send message without sound.
+	DisableNotification bool
 }
 
 type sendMessageResponse struct {
@@ -26,27 +30,80 @@ type KeyboardButton struct {
 	Text string `json:"text"`
 }
 
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton send CallbackData to bot when pressed.
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
 func (t *Telegram) SendMessage(chatID int64, msgID int64, answer Answer) error {
 	form := url.Values{}
 	if msgID > 0 {
 		form.Add("reply_to_message_id", strconv.FormatInt(msgID, 10))
 	}
+	var markup interface{}
 	if answer.ReplyKeyboard != nil {
-		mb, err := json.Marshal(answer.ReplyKeyboard)
+		markup = answer.ReplyKeyboard
+	}
+	if answer.InlineKeyboard != nil {
+		markup = answer.InlineKeyboard
+	}
+	if markup != nil {
+		mb, err := json.Marshal(markup)
 		if err != nil {
 			return fmt.Errorf("Can't marshal markup: %w", err)
 		}
 		form.Add("reply_markup", string(mb))
 	}
+	if answer.DisableNotification {
+		form.Add("disable_notification", "true")
+	}
 	form.Add("chat_id", strconv.FormatInt(chatID, 10))
 	form.Add("text", answer.Text)
+
+	return t.post("sendMessage", form)
+}
+
+// EditInlineKeyboard replace inline keyboard of sent message, nil markup removes keyboard.
+func (t *Telegram) EditInlineKeyboard(chatID int64, msgID int64, markup *InlineKeyboardMarkup) error {
+	if markup == nil {
+		markup = &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	}
+	mb, err := json.Marshal(markup)
+	if err != nil {
+		return fmt.Errorf("Can't marshal markup: %w", err)
+	}
+	form := url.Values{}
+	form.Add("chat_id", strconv.FormatInt(chatID, 10))
+	form.Add("message_id", strconv.FormatInt(msgID, 10))
+	form.Add("reply_markup", string(mb))
+
+	return t.post("editMessageReplyMarkup", form)
+}
+
+// AnswerCallbackQuery confirm that pressed inline button is processed, text is shown as notification.
+func (t *Telegram) AnswerCallbackQuery(queryID string, text string) error {
+	form := url.Values{}
+	form.Add("callback_query_id", queryID)
+	if text != "" {
+		form.Add("text", text)
+	}
+
+	return t.post("answerCallbackQuery", form)
+}
+
+func (t *Telegram) post(method string, form url.Values) error {
 	resp, err := t.client.PostForm(
-		fmt.Sprintf("%s/bot%s/sendMessage", apiURL, t.token),
+		fmt.Sprintf("%s/bot%s/%s", apiURL, t.token, method),
 		form,
 	)
 	if err != nil {
 		t.client.CloseIdleConnections()
-		return fmt.Errorf("Can't send message: %w", err)
+		return fmt.Errorf("Can't send %s: %w", method, err)
 	}
 	defer resp.Body.Close()
 	b, err := ioutil.ReadAll(resp.Body)
@@ -58,7 +115,7 @@ func (t *Telegram) SendMessage(chatID int64, msgID int64, answer Answer) error {
 		return fmt.Errorf("Can't unmarshal body: %q. %v", b, err)
 	}
 	if !smResp.OK {
-		return fmt.Errorf("Can't send message: respons is not OK: %q", b)
+		return fmt.Errorf("Can't send %s: respons is not OK: %q", method, b)
 	}
 
 	return nil
@@ -28,6 +28,15 @@ type User struct {
 type Update struct {
 	UpdateID int64 `json:"update_id"`
 	Message  Message
+	// CallbackQuery is set when inline button is pressed.
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+type CallbackQuery struct {
+	ID      string
+	From    User
+	Message *Message
+	Data    string
 }
 
 type Message struct {
@@ -35,6 +44,8 @@ type Message struct {
 	Text      string
 	From      User
 	Chat      Chat
+	// ReplyMarkup is inline keyboard of message sent by bot.
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup"`
 }
 
 type Chat struct {